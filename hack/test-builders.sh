@@ -0,0 +1,69 @@
+#!/bin/bash
+
+# This command checks that the builder scripts report the result of a build
+# only when it succeeds, and otherwise exit with an error.  It replaces docker
+# with a stub, so it does not require Docker.
+
+set -e
+
+BUILDER_DIR=$(cd $(dirname $0)/../images/builder/docker; pwd)
+TEST_DIR=$(mktemp -d)
+
+function cleanup()
+{
+    set +e
+    rm -rf ${TEST_DIR}
+}
+
+trap cleanup EXIT SIGINT
+
+# the docker stub fails the command named in $FAIL_DOCKER_COMMAND
+mkdir -p ${TEST_DIR}/bin
+cat > ${TEST_DIR}/bin/docker <<'EOF'
+#!/bin/bash
+if [ "$1" == "${FAIL_DOCKER_COMMAND:-}" ]; then
+  echo "docker $1 failed"
+  exit 1
+fi
+if [ "$1" == "inspect" ]; then
+  echo '[{"Id": "abc123"}]'
+fi
+EOF
+printf '#!/bin/bash\n' > ${TEST_DIR}/bin/dind
+chmod +x ${TEST_DIR}/bin/docker ${TEST_DIR}/bin/dind
+
+# run_docker_builder runs the docker builder, failing the given docker command,
+# and checks its exit status and whether it reported an image
+function run_docker_builder()
+{
+    local fail=$1
+    local expected_status=$2
+    local result_file=${TEST_DIR}/result
+    local status=0
+    PATH=${TEST_DIR}/bin:$PATH FAIL_DOCKER_COMMAND=$fail BUILD_RESULT_FILE=$result_file \
+      BUILD_TAG=test DOCKER_REGISTRY=registry:5000 SOURCE_TYPE=dockerfile DOCKERFILE="FROM scratch" \
+      ${BUILDER_DIR}/docker-builder/build.sh > ${TEST_DIR}/log 2>&1 || status=$?
+
+    if [ "$expected_status" == "0" ]; then
+      if ! grep -q "^OPENSHIFT_BUILD_RESULT " $result_file; then
+        echo "docker-builder: expected the image to be reported"
+        cat ${TEST_DIR}/log
+        exit 1
+      fi
+      return
+    fi
+    if [ "$status" == "0" ]; then
+      echo "docker-builder: expected a failure of docker $fail to fail the build"
+      cat ${TEST_DIR}/log
+      exit 1
+    fi
+    if grep -q "^OPENSHIFT_BUILD_RESULT " $result_file; then
+      echo "docker-builder: expected no image to be reported when docker $fail fails"
+      exit 1
+    fi
+}
+
+run_docker_builder "" 0
+run_docker_builder build 1
+run_docker_builder push 1
+echo "docker-builder: ok"
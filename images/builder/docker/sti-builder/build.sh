@@ -1,5 +1,44 @@
 #!/bin/bash -ex
 
+# fetch and install the credentials of the build with tracing disabled, so
+# that they are never printed in the build log. The master serves them once, as
+# a tar archive of files, to the holder of the token of the build pod.
+set +x
+if [ -n "${CREDENTIALS_URL:-}" ]; then
+  CREDENTIALS_DIR=~/.openshift-credentials
+  mkdir -p "$CREDENTIALS_DIR" && chmod 700 "$CREDENTIALS_DIR"
+  curl -sSf -H "Authorization: Bearer $CREDENTIALS_TOKEN" -o "$CREDENTIALS_DIR/credentials.tar" "$CREDENTIALS_URL" || exit 1
+  tar -xf "$CREDENTIALS_DIR/credentials.tar" -C "$CREDENTIALS_DIR" || exit 1
+  rm -f "$CREDENTIALS_DIR/credentials.tar"
+  if [ -f "$CREDENTIALS_DIR/ssh-privatekey" ]; then
+    mkdir -p ~/.ssh && chmod 700 ~/.ssh
+    cp "$CREDENTIALS_DIR/ssh-privatekey" ~/.ssh/id_rsa
+    chmod 600 ~/.ssh/id_rsa
+    echo "StrictHostKeyChecking no" >> ~/.ssh/config
+  fi
+  if [ -f "$CREDENTIALS_DIR/username" ]; then
+    git config --global credential.helper "!f() { echo \"username=\$(cat $CREDENTIALS_DIR/username)\"; echo \"password=\$(cat $CREDENTIALS_DIR/password 2>/dev/null)\"; }; f"
+  fi
+  if [ -f "$CREDENTIALS_DIR/dockercfg" ]; then
+    cp "$CREDENTIALS_DIR/dockercfg" ~/.dockercfg
+    chmod 600 ~/.dockercfg
+  fi
+fi
+unset CREDENTIALS_TOKEN
+set -x
+
+# the revision and the image of the build are reported to the build controller
+# in $BUILD_RESULT_FILE, which is emptied first in case the pod is run again
+report() {
+  echo "$1"
+  if [ -n "${BUILD_RESULT_FILE:-}" ]; then
+    echo "$1" >> "$BUILD_RESULT_FILE"
+  fi
+}
+if [ -n "${BUILD_RESULT_FILE:-}" ]; then
+  : > "$BUILD_RESULT_FILE"
+fi
+
 NEED_DIND=false
 if [ ! -e /var/run/docker.sock ]; then
   NEED_DIND=true
@@ -35,17 +74,69 @@ if [ -n "$DOCKER_REGISTRY" ]; then
   TAG=$DOCKER_REGISTRY/$BUILD_TAG
 fi
 
-REF_OPTION=""
-if [ -n "$SOURCE_REF" ]; then
-  REF_OPTION="--ref $SOURCE_REF"
+# fetch the source of the build into $SOURCE_DIR, the build context is
+# $CONTEXT_DIR within it
+SOURCE_DIR=$(mktemp -d)
+case "$SOURCE_TYPE" in
+  git)
+    git clone --recursive "$SOURCE_URI" "$SOURCE_DIR" || exit 1
+    if [ -n "${SOURCE_REF:-}" ]; then
+      (cd "$SOURCE_DIR" && git checkout "$SOURCE_REF") || exit 1
+    fi
+    ;;
+  dockerfile)
+    echo "$DOCKERFILE" > "$SOURCE_DIR/Dockerfile"
+    ;;
+  archive)
+    curl -sSfL -o /tmp/source-archive "$SOURCE_URI" || exit 1
+    # uploaded archives may be zip files, which start with the "PK" signature
+    if [ "$(head -c 2 /tmp/source-archive)" == "PK" ]; then
+      unzip -q /tmp/source-archive -d "$SOURCE_DIR" || exit 1
+    else
+      tar -xf /tmp/source-archive -C "$SOURCE_DIR" || exit 1
+    fi
+    rm -f /tmp/source-archive
+    ;;
+  *)
+    echo "Unsupported source type $SOURCE_TYPE"
+    exit 1
+    ;;
+esac
+CONTEXT=$SOURCE_DIR/${CONTEXT_DIR:-}
+
+# resolve the builder image to its id, a re-run of a build fails rather than
+# using a builder image which differs from the one of the original build
+docker inspect $BUILDER_IMAGE &> /dev/null || docker pull $BUILDER_IMAGE || exit 1
+RESOLVED_BUILDER_IMAGE_ID=$(docker inspect --format='{{.Id}}' $BUILDER_IMAGE) || exit 1
+if [ -n "${BUILDER_IMAGE_ID:-}" ] && [ "$RESOLVED_BUILDER_IMAGE_ID" != "$BUILDER_IMAGE_ID" ]; then
+  echo "Builder image $BUILDER_IMAGE is $RESOLVED_BUILDER_IMAGE_ID, expected $BUILDER_IMAGE_ID"
+  exit 1
 fi
 
-sti build $SOURCE_URI $BUILDER_IMAGE $TAG $REF_OPTION
+# report the revision of the build to the build controller, so that the build
+# can be re-run with identical inputs
+COMMIT=""
+if [ "$SOURCE_TYPE" == "git" ]; then
+  COMMIT=$(cd "$SOURCE_DIR" && git rev-parse HEAD) || exit 1
+fi
+report "OPENSHIFT_BUILD_REVISION {\"commit\":\"$COMMIT\",\"builderImageID\":\"$RESOLVED_BUILDER_IMAGE_ID\"}"
+
+# the environment of the build is passed on to the assemble script, one
+# variable per --env option so that values may contain any character
+ENV_OPTION=()
+for name in ${STI_ENV_NAMES:-}; do
+  ENV_OPTION+=(--env "$name=${!name}")
+done
+
+sti build "$CONTEXT" $BUILDER_IMAGE $TAG "${ENV_OPTION[@]}"
 
 if [ -n "$DOCKER_REGISTRY" ]; then
   docker push $TAG
 fi
 
+# report the image to the build controller, which records it in the image registry
+report "OPENSHIFT_BUILD_RESULT $(docker inspect $TAG | tr -d '\n')"
+
 if [ $NEED_DIND == "true" ]; then
   kill -15 $(cat /var/run/docker.pid)
 fi
@@ -0,0 +1,3 @@
+// Package apiserver contains OpenShift extensions to the Kubernetes API server,
+// such as operations on individual resources that are not plain REST storage.
+package apiserver
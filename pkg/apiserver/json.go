@@ -0,0 +1,46 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
+)
+
+// statusError is an object that can be converted into an api.Status
+type statusError interface {
+	Status() api.Status
+}
+
+// WriteJSON renders an API object to the response, serialized by codec.
+func WriteJSON(statusCode int, codec apiserver.Codec, object interface{}, w http.ResponseWriter) {
+	output, err := codec.Encode(object)
+	if err != nil {
+		ErrorJSON(err, codec, w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(output)
+}
+
+// ErrorJSON renders an error to the response as an api.Status object.
+func ErrorJSON(err error, codec apiserver.Codec, w http.ResponseWriter) {
+	status := errToAPIStatus(err)
+	WriteJSON(status.Code, codec, status, w)
+}
+
+// errToAPIStatus converts an error to an api.Status object.
+func errToAPIStatus(err error) *api.Status {
+	if t, ok := err.(statusError); ok {
+		status := t.Status()
+		status.Status = api.StatusFailure
+		return &status
+	}
+	return &api.Status{
+		Status:  api.StatusFailure,
+		Code:    http.StatusInternalServerError,
+		Reason:  api.StatusReasonUnknown,
+		Message: err.Error(),
+	}
+}
@@ -0,0 +1,57 @@
+package apiserver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Subresource serves an operation on the individual resource identified by id.
+type Subresource interface {
+	ServeSubresource(w http.ResponseWriter, req *http.Request, id string)
+}
+
+// SubresourceHandler routes requests of the form /{resource}/{id}/{subresource}
+// to the Subresource registered for that resource and operation. All other
+// requests are passed through to the delegate, which is normally the REST
+// handler installed for the same prefix.
+type SubresourceHandler struct {
+	prefix       string
+	delegate     http.Handler
+	subresources map[string]Subresource
+}
+
+// NewSubresourceHandler creates a SubresourceHandler serving paths below prefix.
+func NewSubresourceHandler(prefix string, delegate http.Handler) *SubresourceHandler {
+	return &SubresourceHandler{
+		prefix:       strings.TrimRight(prefix, "/"),
+		delegate:     delegate,
+		subresources: make(map[string]Subresource),
+	}
+}
+
+// Handle registers the handler for an operation on the given resource.
+func (h *SubresourceHandler) Handle(resource, subresource string, handler Subresource) {
+	h.subresources[resource+"/"+subresource] = handler
+}
+
+// ServeHTTP dispatches subresource requests and delegates everything else.
+func (h *SubresourceHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if strings.HasPrefix(req.URL.Path, h.prefix+"/") {
+		parts := splitPath(strings.TrimPrefix(req.URL.Path, h.prefix))
+		if len(parts) == 3 {
+			if handler, ok := h.subresources[parts[0]+"/"+parts[2]]; ok {
+				handler.ServeSubresource(w, req, parts[1])
+				return
+			}
+		}
+	}
+	h.delegate.ServeHTTP(w, req)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
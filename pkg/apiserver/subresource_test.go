@@ -0,0 +1,64 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingSubresource struct {
+	id string
+}
+
+func (r *recordingSubresource) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	r.id = id
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestSubresourceHandlerDispatch(t *testing.T) {
+	delegated := false
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		delegated = true
+	})
+	subresource := &recordingSubresource{}
+	handler := NewSubresourceHandler("/osapi/v1beta1", delegate)
+	handler.Handle("builds", "log", subresource)
+
+	req, _ := http.NewRequest("GET", "/osapi/v1beta1/builds/foo/log", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if delegated {
+		t.Errorf("Unexpected delegation of a subresource request")
+	}
+	if e, a := "foo", subresource.id; e != a {
+		t.Errorf("Expected %s, got %s", e, a)
+	}
+}
+
+func TestSubresourceHandlerDelegates(t *testing.T) {
+	subresource := &recordingSubresource{}
+	for _, path := range []string{
+		"/osapi/v1beta1/builds",
+		"/osapi/v1beta1/builds/foo",
+		"/osapi/v1beta1/builds/foo/unknown",
+		"/osapi/v1beta1/buildConfigs/foo/log",
+		"/osapi/v1beta1/watch/builds",
+	} {
+		delegated := false
+		delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			delegated = true
+		})
+		handler := NewSubresourceHandler("/osapi/v1beta1/", delegate)
+		handler.Handle("builds", "log", subresource)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !delegated {
+			t.Errorf("%s: expected the request to be delegated", path)
+		}
+	}
+	if len(subresource.id) != 0 {
+		t.Errorf("Unexpected subresource request for %s", subresource.id)
+	}
+}
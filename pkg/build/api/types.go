@@ -1,7 +1,10 @@
 package api
 
 import (
+	"time"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 // Build encapsulates the inputs needed to produce a new deployable image, as well as
@@ -16,8 +19,96 @@ type Build struct {
 	// Status is the current status of the build
 	Status BuildStatus `json:"status,omitempty" yaml:"status,omitempty"`
 
+	// Reason is a machine readable explanation of why the build is in its
+	// current status, set when the build did not complete successfully
+	Reason BuildStatusReason `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// Message is a human readable description of the status of the build
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// QueuePosition is the 1-based position of a queued build in the queue of
+	// builds waiting for a free build slot, it is 0 for builds which are not queued
+	QueuePosition int `json:"queuePosition,omitempty" yaml:"queuePosition,omitempty"`
+
 	// PodID is the id of the pod that is used to execute the build
 	PodID string `json:"podID,omitempty" yaml:"podID,omitempty"`
+
+	// Attempt is the number of the current attempt to execute the build. It
+	// starts at 1 and is incremented when the build pod is recreated after an error.
+	Attempt int `json:"attempt,omitempty" yaml:"attempt,omitempty"`
+
+	// RetryTimestamp is the time until which a build which is retried after an
+	// error waits before recreating its pod
+	RetryTimestamp util.Time `json:"retryTimestamp,omitempty" yaml:"retryTimestamp,omitempty"`
+
+	// Config is a reference to the BuildConfig this build was created from, if any
+	Config *BuildConfigReference `json:"config,omitempty" yaml:"config,omitempty"`
+
+	// ImageChangeCause is set when the build was triggered by a tag of an
+	// ImageRepository moving to a new image
+	ImageChangeCause *ImageChangeCause `json:"imageChangeCause,omitempty" yaml:"imageChangeCause,omitempty"`
+
+	// UpstreamBuildCause is set when the build was triggered by the completion
+	// of a build of an upstream BuildConfig
+	UpstreamBuildCause *UpstreamBuildCause `json:"upstreamBuildCause,omitempty" yaml:"upstreamBuildCause,omitempty"`
+
+	// DownstreamPending is set when the build completes, until a build of every
+	// BuildConfig downstream of the BuildConfig of the build has been created
+	DownstreamPending bool `json:"downstreamPending,omitempty" yaml:"downstreamPending,omitempty"`
+
+	// Cancelled is set when cancellation of the build has been requested
+	Cancelled bool `json:"cancelled,omitempty" yaml:"cancelled,omitempty"`
+
+	// CancelledBy identifies who requested cancellation of the build
+	CancelledBy string `json:"cancelledBy,omitempty" yaml:"cancelledBy,omitempty"`
+
+	// CancellationTimestamp is the time at which cancellation of the build was requested
+	CancellationTimestamp util.Time `json:"cancellationTimestamp,omitempty" yaml:"cancellationTimestamp,omitempty"`
+
+	// StartTimestamp is the time at which the build pod started running
+	StartTimestamp util.Time `json:"startTimestamp,omitempty" yaml:"startTimestamp,omitempty"`
+
+	// CompletionTimestamp is the time at which the build reached a terminal status
+	CompletionTimestamp util.Time `json:"completionTimestamp,omitempty" yaml:"completionTimestamp,omitempty"`
+
+	// Duration is the time the build spent running, from StartTimestamp to CompletionTimestamp
+	Duration time.Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+
+	// StatusHistory lists the status transitions of the build, oldest first
+	StatusHistory []BuildStatusTransition `json:"statusHistory,omitempty" yaml:"statusHistory,omitempty"`
+
+	// Revision identifies the exact source and builder image the build used, as
+	// reported by the builder. A build cloned from another build is pinned to
+	// the revision of the original build.
+	Revision *BuildRevision `json:"revision,omitempty" yaml:"revision,omitempty"`
+
+	// ClonedFrom is the id of the build this build re-runs, if any
+	ClonedFrom string `json:"clonedFrom,omitempty" yaml:"clonedFrom,omitempty"`
+}
+
+// BuildRevision identifies the resolved inputs of a build
+type BuildRevision struct {
+	// Commit is the id of the git commit which was built
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+
+	// BuilderImageID is the id of the image which executed an STI build
+	BuilderImageID string `json:"builderImageID,omitempty" yaml:"builderImageID,omitempty"`
+}
+
+// BuildStatusTransition records a change of the status of a build
+type BuildStatusTransition struct {
+	// From is the status the build left
+	From BuildStatus `json:"from,omitempty" yaml:"from,omitempty"`
+
+	// To is the status the build entered
+	To BuildStatus `json:"to,omitempty" yaml:"to,omitempty"`
+
+	// Timestamp is the time of the transition
+	Timestamp util.Time `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+
+	// Message is a human readable description of the transition, it is empty
+	// when the transition did not change the message of the build
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
 }
 
 // BuildInput defines the type of build and input parameters for a given build
@@ -25,18 +116,116 @@ type BuildInput struct {
 	// Type is the type of build to execute
 	Type BuildType `json:"type,omitempty" yaml:"type,omitempty"`
 
-	// SourceURI points to the source that will be built. The structure of the source
-	// will depend on the type of build to run
-	SourceURI string `json:"sourceURI,omitempty" yaml:"sourceURI,omitempty"`
+	// Source is the source that will be built
+	Source BuildSource `json:"source,omitempty" yaml:"source,omitempty"`
 
-	// SourceRef is the branch/tag/ref to build.
-	SourceRef string `json:"sourceRef,omitempty" yaml:"sourceRef,omitempty"`
+	// Env contains environment variables passed to the build. They are set in
+	// the builder container, the STI builder forwards them to the assemble script.
+	Env []api.EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
 
 	// ImageTag is the tag to give to the image resulting from the build
 	ImageTag string `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
 
 	// BuilderImage is the image used to execute the build when running STI builds
 	BuilderImage string `json:"builderImage,omitempty" yaml:"builderImage,omitempty"`
+
+	// BaseImage is the image named in the FROM instruction of the Dockerfile of
+	// a docker build. It is only used to rebuild when the image changes.
+	BaseImage string `json:"baseImage,omitempty" yaml:"baseImage,omitempty"`
+
+	// Custom holds the parameters of a custom build, it must be set if and only
+	// if Type is custom
+	Custom *CustomBuildInput `json:"custom,omitempty" yaml:"custom,omitempty"`
+
+	// PodOptions customizes the pod which executes the build
+	PodOptions *BuildPodOptions `json:"podOptions,omitempty" yaml:"podOptions,omitempty"`
+
+	// TimeoutSeconds is the number of seconds a build may run before it is failed.
+	// When unset, the timeout configured for the build controller applies.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+}
+
+// BuildSource is the source of a build. The member matching Type must be set,
+// the others must be empty.
+type BuildSource struct {
+	// Type is the type of the source
+	Type BuildSourceType `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Git is a git repository to clone
+	Git *GitBuildSource `json:"git,omitempty" yaml:"git,omitempty"`
+
+	// Dockerfile is the content of a Dockerfile, which is the only file in the
+	// build context
+	Dockerfile string `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty"`
+
+	// Archive is a tar or zip archive downloaded over http(s)
+	Archive *ArchiveBuildSource `json:"archive,omitempty" yaml:"archive,omitempty"`
+}
+
+// BuildSourceType is the type of source of a build
+type BuildSourceType string
+
+// Valid values for BuildSourceType.
+const (
+	// GitBuildSourceType builds the content of a git repository
+	GitBuildSourceType BuildSourceType = "git"
+
+	// DockerfileBuildSourceType builds an inline Dockerfile
+	DockerfileBuildSourceType BuildSourceType = "dockerfile"
+
+	// ArchiveBuildSourceType builds the content of an archive
+	ArchiveBuildSourceType BuildSourceType = "archive"
+)
+
+// GitBuildSource is a git repository to build
+type GitBuildSource struct {
+	// URI is the location of the repository
+	URI string `json:"uri,omitempty" yaml:"uri,omitempty"`
+
+	// Ref is the branch/tag/ref to build, the default branch is built when unset
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+
+	// ContextDir is the subdirectory of the repository which is built, the root
+	// of the repository is built when unset
+	ContextDir string `json:"contextDir,omitempty" yaml:"contextDir,omitempty"`
+}
+
+// ArchiveBuildSource is an archive to build
+type ArchiveBuildSource struct {
+	// URL is the http(s) location of a zip archive or a tar archive, which may be
+	// compressed
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// CustomBuildInput defines a build executed by a user supplied builder image.
+// The builder container receives the serialized Build in the BUILD environment
+// variable and is responsible for fetching the source and pushing the image.
+type CustomBuildInput struct {
+	// Image is the builder image that executes the build
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// Env contains additional environment variables passed to the builder container
+	Env []api.EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// ExposeDockerSocket mounts the Docker socket of the minion into the builder
+	// container, allowing it to run docker build/push against the host daemon
+	ExposeDockerSocket bool `json:"exposeDockerSocket,omitempty" yaml:"exposeDockerSocket,omitempty"`
+}
+
+// BuildPodOptions customizes the pod which executes a build
+type BuildPodOptions struct {
+	// Memory is the memory limit of the builder container in bytes, 0 means no limit
+	Memory int `json:"memory,omitempty" yaml:"memory,omitempty"`
+
+	// CPU is the relative CPU share of the builder container, 0 means the default share
+	CPU int `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+
+	// Labels are added to the labels of the build pod
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	// Minions restricts the build pod to the listed minions, so that builds can
+	// run on dedicated build minions. When empty the pod may run on any minion.
+	Minions []string `json:"minions,omitempty" yaml:"minions,omitempty"`
 }
 
 // BuildConfig contains the inputs needed to produce a new deployable image
@@ -49,8 +238,117 @@ type BuildConfig struct {
 
 	// Secret used to validate requests.
 	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// MaxConcurrentBuilds is the maximum number of builds of this configuration
+	// which may run at the same time, further builds are queued. 0 means no limit.
+	MaxConcurrentBuilds int `json:"maxConcurrentBuilds,omitempty" yaml:"maxConcurrentBuilds,omitempty"`
+
+	// Credentials are used by the builds of this configuration to fetch their
+	// source and push their output. They are kept by the server and never
+	// returned by the API, an update without credentials keeps the stored ones
+	// and an update with empty credentials removes them.
+	Credentials *BuildCredentials `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+
+	// RetryPolicy controls the retries of builds which end in BuildError. Builds
+	// are not retried when it is unset.
+	RetryPolicy *BuildRetryPolicy `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+
+	// Upstream lists the ids of the BuildConfigs whose completed builds trigger a
+	// build of this configuration, eg. the configurations of the images it builds
+	// FROM. The upstream relations of all BuildConfigs may not form a cycle.
+	Upstream []string `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+
+	// LastVersion is the sequence number of the most recent build created
+	// from this configuration
+	LastVersion int `json:"lastVersion,omitempty" yaml:"lastVersion,omitempty"`
+}
+
+// BuildRetryPolicy controls how often and when a build whose pod could not be
+// created or disappeared is executed again. Builds which fail are never retried.
+type BuildRetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts to execute a build,
+	// including the first one
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+
+	// BackoffSeconds is the delay before the first retry, it doubles with every
+	// further retry
+	BackoffSeconds int `json:"backoffSeconds,omitempty" yaml:"backoffSeconds,omitempty"`
+
+	// MaxBackoffSeconds caps the delay between retries, 0 means no cap
+	MaxBackoffSeconds int `json:"maxBackoffSeconds,omitempty" yaml:"maxBackoffSeconds,omitempty"`
+}
+
+// BuildCredentials hold the secrets a build needs to fetch its source and to
+// push its output image
+type BuildCredentials struct {
+	// Source authenticates the build against the source repository
+	Source *SourceCredentials `json:"source,omitempty" yaml:"source,omitempty"`
+
+	// DockerConfig is the content of a .dockercfg file used to push the output image
+	DockerConfig string `json:"dockerConfig,omitempty" yaml:"dockerConfig,omitempty"`
+}
+
+// SourceCredentials authenticate a build against its source repository, either
+// with an SSH private key or with a username and password
+type SourceCredentials struct {
+	// SSHPrivateKey is the private key used to clone the source over ssh
+	SSHPrivateKey string `json:"sshPrivateKey,omitempty" yaml:"sshPrivateKey,omitempty"`
+
+	// Username is the user used to clone the source over http(s)
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+
+	// Password is the password of Username
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// BuildConfigReference identifies the BuildConfig a Build was created from
+type BuildConfigReference struct {
+	// ID is the id of the BuildConfig
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// Version is the sequence number the build was given by the BuildConfig
+	Version int `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ImageChangeCause identifies the image change which triggered a build
+type ImageChangeCause struct {
+	// ImageRepository is the id of the ImageRepository whose tag changed
+	ImageRepository string `json:"imageRepository,omitempty" yaml:"imageRepository,omitempty"`
+
+	// Tag is the tag of the ImageRepository which changed
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+
+	// ImageID is the id of the image the tag now points to
+	ImageID string `json:"imageID,omitempty" yaml:"imageID,omitempty"`
 }
 
+// UpstreamBuildCause identifies the build of an upstream BuildConfig whose
+// completion triggered a build
+type UpstreamBuildCause struct {
+	// BuildConfig is the id of the upstream BuildConfig
+	BuildConfig string `json:"buildConfig,omitempty" yaml:"buildConfig,omitempty"`
+
+	// Build is the id of the upstream build which completed
+	Build string `json:"build,omitempty" yaml:"build,omitempty"`
+}
+
+// BuildConfigLabel is the label set on every build created from a BuildConfig,
+// its value is the id of the BuildConfig. It can be used to list all builds of
+// a given configuration. It is also set on build pods.
+const BuildConfigLabel = "buildconfig"
+
+// BuildLabel is the label set on every build pod, its value is the id of the build.
+// It is namespaced so that it does not collide with the labels of other pods.
+const BuildLabel = "openshift.io/build"
+
+// BuildStatusLabel is set by the registry on every build it stores, its value is
+// the status of the build. It allows listing only the builds in a given status.
+const BuildStatusLabel = "openshift.io/build-status"
+
+// DownstreamPendingLabel is set to "true" by the registry on the builds whose
+// downstream builds have not been triggered yet.
+const DownstreamPendingLabel = "openshift.io/downstream-pending"
+
 // BuildType is a type of build (docker, sti, etc)
 type BuildType string
 
@@ -62,6 +360,9 @@ const (
 	// STIBuildType is a build using Source to Image using a git repository
 	// and a builder image
 	STIBuildType BuildType = "sti"
+
+	// CustomBuildType is a build executed by a builder image supplied by the user
+	CustomBuildType BuildType = "custom"
 )
 
 // BuildStatus represents the status of a Build at a point in time.
@@ -72,6 +373,10 @@ const (
 	// BuildNew is automatically assigned to a newly created build
 	BuildNew BuildStatus = "new"
 
+	// BuildQueued indicates that a build is waiting for a free build slot
+	// because a concurrency limit has been reached
+	BuildQueued BuildStatus = "queued"
+
 	// BuildPending indicates that a pod name has been assigned and a build is
 	// about to start running
 	BuildPending BuildStatus = "pending"
@@ -88,6 +393,39 @@ const (
 	// BuildError indicates that an error prevented the build from
 	// executing
 	BuildError BuildStatus = "error"
+
+	// BuildCancelled indicates that a running/pending build was stopped
+	// at the request of a user
+	BuildCancelled BuildStatus = "cancelled"
+)
+
+// BuildStatusReason explains why a build reached its current status.
+type BuildStatusReason string
+
+// Valid build status reasons
+const (
+	// BuildReasonTimedOut indicates that the build ran for longer than its timeout
+	BuildReasonTimedOut BuildStatusReason = "timed_out"
+
+	// BuildReasonPodCreationRejected indicates that the pod executing the build
+	// could not be created
+	BuildReasonPodCreationRejected BuildStatusReason = "pod_creation_rejected"
+
+	// BuildReasonPodCreationFailed indicates that the pod executing the build
+	// could not be created because of an error of the server
+	BuildReasonPodCreationFailed BuildStatusReason = "pod_creation_failed"
+
+	// BuildReasonPodDeleted indicates that the pod executing the build
+	// disappeared before the build finished
+	BuildReasonPodDeleted BuildStatusReason = "pod_deleted"
+
+	// BuildReasonContainerFailed indicates that one or more containers of the
+	// build pod exited with a non-zero exit code
+	BuildReasonContainerFailed BuildStatusReason = "container_failed"
+
+	// BuildReasonUnknownStrategy indicates that no build strategy is registered
+	// for the type of the build
+	BuildReasonUnknownStrategy BuildStatusReason = "unknown_strategy"
 )
 
 // BuildList is a collection of Builds.
@@ -101,3 +439,20 @@ type BuildConfigList struct {
 	api.JSONBase `json:",inline" yaml:",inline"`
 	Items        []BuildConfig `json:"items,omitempty" yaml:"items,omitempty"`
 }
+
+// BuildRequest is the body of a request to instantiate a BuildConfig. Its fields
+// override the DesiredInput of the BuildConfig for the single build it creates.
+type BuildRequest struct {
+	api.JSONBase `json:",inline" yaml:",inline"`
+
+	// Ref is the git ref to build instead of the ref of the git source
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+
+	// Env contains environment variables which replace or are added to the
+	// environment variables of the build
+	Env []api.EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// BuilderImage is the image executing an STI or custom build instead of the
+	// builder image of the BuildConfig
+	BuilderImage string `json:"builderImage,omitempty" yaml:"builderImage,omitempty"`
+}
@@ -0,0 +1,67 @@
+package v1beta1
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+
+	newer "github.com/openshift/origin/pkg/build/api"
+)
+
+func init() {
+	// Shortcut for sub-conversions.
+	Convert := runtime.Convert
+	runtime.AddConversionFuncs(
+		// SourceURI and SourceRef are deprecated in favor of Source.
+		func(in *newer.BuildInput, out *BuildInput) error {
+			out.Type = BuildType(in.Type)
+			if err := Convert(&in.Source, &out.Source); err != nil {
+				return err
+			}
+			if in.Source.Type == newer.GitBuildSourceType && in.Source.Git != nil {
+				out.SourceURI = in.Source.Git.URI
+				out.SourceRef = in.Source.Git.Ref
+			}
+			if err := Convert(&in.Env, &out.Env); err != nil {
+				return err
+			}
+			out.ImageTag = in.ImageTag
+			out.BuilderImage = in.BuilderImage
+			out.BaseImage = in.BaseImage
+			if err := Convert(&in.Custom, &out.Custom); err != nil {
+				return err
+			}
+			if err := Convert(&in.PodOptions, &out.PodOptions); err != nil {
+				return err
+			}
+			out.TimeoutSeconds = in.TimeoutSeconds
+			return nil
+		},
+		func(in *BuildInput, out *newer.BuildInput) error {
+			out.Type = newer.BuildType(in.Type)
+			if in.Source.Type == "" && in.SourceURI != "" {
+				out.Source = newer.BuildSource{
+					Type: newer.GitBuildSourceType,
+					Git: &newer.GitBuildSource{
+						URI: in.SourceURI,
+						Ref: in.SourceRef,
+					},
+				}
+			} else if err := Convert(&in.Source, &out.Source); err != nil {
+				return err
+			}
+			if err := Convert(&in.Env, &out.Env); err != nil {
+				return err
+			}
+			out.ImageTag = in.ImageTag
+			out.BuilderImage = in.BuilderImage
+			out.BaseImage = in.BaseImage
+			if err := Convert(&in.Custom, &out.Custom); err != nil {
+				return err
+			}
+			if err := Convert(&in.PodOptions, &out.PodOptions); err != nil {
+				return err
+			}
+			out.TimeoutSeconds = in.TimeoutSeconds
+			return nil
+		},
+	)
+}
@@ -1,10 +1,17 @@
 package validation
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
+	"path"
+	"strings"
 
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
 )
 
 // ValidateBuild tests required fields for a Build.
@@ -24,16 +31,73 @@ func ValidateBuildConfig(config *api.BuildConfig) errs.ErrorList {
 		allErrs = append(allErrs, errs.NewFieldRequired("id", config.ID))
 	}
 	allErrs = append(allErrs, validateBuildInput(&config.DesiredInput).Prefix("desiredInput")...)
+	if config.MaxConcurrentBuilds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("maxConcurrentBuilds", config.MaxConcurrentBuilds))
+	}
+	if config.RetryPolicy != nil {
+		allErrs = append(allErrs, validateRetryPolicy(config.RetryPolicy).Prefix("retryPolicy")...)
+	}
+	if config.Credentials != nil {
+		allErrs = append(allErrs, validateCredentials(config.Credentials).Prefix("credentials")...)
+	}
+	allErrs = append(allErrs, validateUpstream(config).Prefix("upstream")...)
 	return allErrs
 }
 
-func validateBuildInput(input *api.BuildInput) errs.ErrorList {
+// ValidateBuildConfigUpstream tests that the upstream relations of config do
+// not form a cycle with the upstream relations of the existing configs, which
+// would trigger builds endlessly. config replaces the existing config with the
+// same id.
+func ValidateBuildConfigUpstream(config *api.BuildConfig, configs []api.BuildConfig) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	upstream := map[string][]string{}
+	for i := range configs {
+		upstream[configs[i].ID] = configs[i].Upstream
+	}
+	upstream[config.ID] = config.Upstream
+
+	if cycle := findUpstreamCycle(config.ID, upstream, []string{config.ID}, map[string]bool{}); cycle != nil {
+		allErrs = append(allErrs, errs.NewFieldInvalid("upstream", strings.Join(cycle, " <- ")))
+	}
+	return allErrs
+}
+
+// findUpstreamCycle returns the path from the last config of path back to id
+// through the upstream relations, or nil if there is none. Configs already
+// visited have no path to id.
+func findUpstreamCycle(id string, upstream map[string][]string, path []string, visited map[string]bool) []string {
+	for _, next := range upstream[path[len(path)-1]] {
+		if next == id {
+			return append(path, next)
+		}
+		if visited[next] {
+			continue
+		}
+		visited[next] = true
+		if cycle := findUpstreamCycle(id, upstream, append(path, next), visited); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// ValidateBuildRequest tests that the overrides of request can be applied to input.
+func ValidateBuildRequest(request *api.BuildRequest, input *api.BuildInput) errs.ErrorList {
 	allErrs := errs.ErrorList{}
-	if len(input.SourceURI) == 0 {
-		allErrs = append(allErrs, errs.NewFieldRequired("sourceURI", input.SourceURI))
-	} else if !isValidURL(input.SourceURI) {
-		allErrs = append(allErrs, errs.NewFieldInvalid("sourceURI", input.SourceURI))
+	if len(request.Ref) > 0 && input.Source.Type != api.GitBuildSourceType {
+		allErrs = append(allErrs, errs.NewFieldNotSupported("ref", request.Ref))
+	}
+	allErrs = append(allErrs, validateEnv(request.Env).Prefix("env")...)
+	if len(request.BuilderImage) > 0 && input.Type != api.STIBuildType && input.Type != api.CustomBuildType {
+		allErrs = append(allErrs, errs.NewFieldNotSupported("builderImage", request.BuilderImage))
 	}
+	return allErrs
+}
+
+func validateBuildInput(input *api.BuildInput) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	allErrs = append(allErrs, validateSource(&input.Source).Prefix("source")...)
+	allErrs = append(allErrs, validateEnv(input.Env).Prefix("env")...)
 	if len(input.ImageTag) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("imageTag", input.ImageTag))
 	}
@@ -46,6 +110,179 @@ func validateBuildInput(input *api.BuildInput) errs.ErrorList {
 			allErrs = append(allErrs, errs.NewFieldInvalid("builderImage", input.BuilderImage))
 		}
 	}
+	if len(input.BaseImage) != 0 && input.Type != api.DockerBuildType {
+		allErrs = append(allErrs, errs.NewFieldInvalid("baseImage", input.BaseImage))
+	}
+	if input.Type == api.CustomBuildType {
+		if input.Custom == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("custom", input.Custom))
+		} else {
+			allErrs = append(allErrs, validateCustomBuildInput(input.Custom).Prefix("custom")...)
+		}
+	} else {
+		if input.Custom != nil {
+			allErrs = append(allErrs, errs.NewFieldInvalid("custom", input.Custom))
+		}
+	}
+	if input.PodOptions != nil {
+		allErrs = append(allErrs, validatePodOptions(input.PodOptions).Prefix("podOptions")...)
+	}
+	if input.TimeoutSeconds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("timeoutSeconds", input.TimeoutSeconds))
+	}
+	return allErrs
+}
+
+func validateSource(source *api.BuildSource) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	switch source.Type {
+	case api.GitBuildSourceType:
+		if source.Git == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("git", source.Git))
+		} else {
+			allErrs = append(allErrs, validateGitSource(source.Git).Prefix("git")...)
+		}
+	case api.DockerfileBuildSourceType:
+		if len(source.Dockerfile) == 0 {
+			allErrs = append(allErrs, errs.NewFieldRequired("dockerfile", source.Dockerfile))
+		}
+	case api.ArchiveBuildSourceType:
+		if source.Archive == nil {
+			allErrs = append(allErrs, errs.NewFieldRequired("archive", source.Archive))
+		} else if !isValidHTTPURL(source.Archive.URL) {
+			allErrs = append(allErrs, errs.NewFieldInvalid("archive.url", source.Archive.URL))
+		}
+	case "":
+		allErrs = append(allErrs, errs.NewFieldRequired("type", source.Type))
+	default:
+		allErrs = append(allErrs, errs.NewFieldNotSupported("type", source.Type))
+	}
+
+	// only the member matching the type may be set
+	if source.Git != nil && source.Type != api.GitBuildSourceType {
+		allErrs = append(allErrs, errs.NewFieldInvalid("git", source.Git))
+	}
+	if len(source.Dockerfile) != 0 && source.Type != api.DockerfileBuildSourceType {
+		allErrs = append(allErrs, errs.NewFieldInvalid("dockerfile", source.Dockerfile))
+	}
+	if source.Archive != nil && source.Type != api.ArchiveBuildSourceType {
+		allErrs = append(allErrs, errs.NewFieldInvalid("archive", source.Archive))
+	}
+	return allErrs
+}
+
+func validateGitSource(git *api.GitBuildSource) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if len(git.URI) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("uri", git.URI))
+	} else if !isValidURL(git.URI) {
+		allErrs = append(allErrs, errs.NewFieldInvalid("uri", git.URI))
+	}
+	if len(git.ContextDir) != 0 && !isValidContextDir(git.ContextDir) {
+		allErrs = append(allErrs, errs.NewFieldInvalid("contextDir", git.ContextDir))
+	}
+	return allErrs
+}
+
+func validateCustomBuildInput(custom *api.CustomBuildInput) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if len(custom.Image) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("image", custom.Image))
+	}
+	allErrs = append(allErrs, validateEnv(custom.Env).Prefix("env")...)
+	return allErrs
+}
+
+func validateUpstream(config *api.BuildConfig) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	seen := map[string]bool{}
+	for i, id := range config.Upstream {
+		switch {
+		case len(id) == 0:
+			allErrs = append(allErrs, errs.NewFieldRequired(fmt.Sprintf("[%d]", i), id))
+		case id == config.ID:
+			allErrs = append(allErrs, errs.NewFieldInvalid(fmt.Sprintf("[%d]", i), id))
+		case seen[id]:
+			allErrs = append(allErrs, errs.NewFieldDuplicate(fmt.Sprintf("[%d]", i), id))
+		}
+		seen[id] = true
+	}
+	return allErrs
+}
+
+func validateRetryPolicy(policy *api.BuildRetryPolicy) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if policy.MaxAttempts < 1 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("maxAttempts", policy.MaxAttempts))
+	}
+	if policy.BackoffSeconds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("backoffSeconds", policy.BackoffSeconds))
+	}
+	if policy.MaxBackoffSeconds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("maxBackoffSeconds", policy.MaxBackoffSeconds))
+	}
+	return allErrs
+}
+
+// validateCredentials never reports the secret values in its errors, as they
+// are returned to the client.
+func validateCredentials(credentials *api.BuildCredentials) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if source := credentials.Source; source != nil {
+		hasKey, hasUser := len(source.SSHPrivateKey) > 0, len(source.Username) > 0
+		switch {
+		case hasKey && hasUser:
+			allErrs = append(allErrs, errs.NewFieldInvalid("source.username", "sshPrivateKey and username are mutually exclusive"))
+		case !hasKey && !hasUser:
+			allErrs = append(allErrs, errs.NewFieldRequired("source.sshPrivateKey", ""))
+		case hasUser && len(source.Password) == 0:
+			allErrs = append(allErrs, errs.NewFieldRequired("source.password", ""))
+		}
+	}
+	if len(credentials.DockerConfig) > 0 {
+		var auths map[string]interface{}
+		if err := json.Unmarshal([]byte(credentials.DockerConfig), &auths); err != nil {
+			allErrs = append(allErrs, errs.NewFieldInvalid("dockerConfig", "not a valid .dockercfg"))
+		}
+	}
+	return allErrs
+}
+
+func validatePodOptions(options *api.BuildPodOptions) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if options.Memory < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("memory", options.Memory))
+	}
+	if options.CPU < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("cpu", options.CPU))
+	}
+	for key := range options.Labels {
+		if key == api.BuildLabel || key == api.BuildConfigLabel {
+			allErrs = append(allErrs, errs.NewFieldInvalid("labels", key))
+		}
+	}
+	for i, minion := range options.Minions {
+		if len(minion) == 0 {
+			allErrs = append(allErrs, errs.NewFieldRequired(fmt.Sprintf("minions[%d]", i), minion))
+		}
+	}
+	return allErrs
+}
+
+func validateEnv(vars []kubeapi.EnvVar) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	for i := range vars {
+		vErrs := errs.ErrorList{}
+		ev := &vars[i]
+		if len(ev.Name) == 0 {
+			vErrs = append(vErrs, errs.NewFieldRequired("name", ev.Name))
+		} else if !util.IsCIdentifier(ev.Name) {
+			vErrs = append(vErrs, errs.NewFieldInvalid("name", ev.Name))
+		} else if strategy.ReservedEnvNames[ev.Name] {
+			vErrs = append(vErrs, errs.NewFieldNotSupported("name", ev.Name))
+		}
+		allErrs = append(allErrs, vErrs.PrefixIndex(i)...)
+	}
 	return allErrs
 }
 
@@ -53,3 +290,18 @@ func isValidURL(uri string) bool {
 	_, err := url.Parse(uri)
 	return err == nil
 }
+
+func isValidHTTPURL(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// isValidContextDir returns true if dir is a relative path which stays within
+// the source of the build.
+func isValidContextDir(dir string) bool {
+	if path.IsAbs(dir) {
+		return false
+	}
+	cleaned := path.Clean(dir)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
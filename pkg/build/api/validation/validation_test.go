@@ -1,9 +1,11 @@
 package validation
 
 import (
+	"strings"
 	"testing"
 
 	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/openshift/origin/pkg/build/api"
 )
 
@@ -11,9 +13,9 @@ func TestBuildValdationSuccess(t *testing.T) {
 	build := &api.Build{
 		JSONBase: kubeapi.JSONBase{ID: "buildId"},
 		Input: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://github.com/my/repository",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
 		},
 		Status: api.BuildNew,
 	}
@@ -26,9 +28,9 @@ func TestBuildValidationFailure(t *testing.T) {
 	build := &api.Build{
 		JSONBase: kubeapi.JSONBase{ID: ""},
 		Input: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://github.com/my/repository",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
 		},
 		Status: api.BuildNew,
 	}
@@ -41,9 +43,9 @@ func TestBuildConfigValidationSuccess(t *testing.T) {
 	buildConfig := &api.BuildConfig{
 		JSONBase: kubeapi.JSONBase{ID: "configId"},
 		DesiredInput: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://github.com/my/repository",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
 		},
 	}
 	if result := ValidateBuildConfig(buildConfig); len(result) > 0 {
@@ -55,9 +57,9 @@ func TestBuildConfigValidationFailure(t *testing.T) {
 	buildConfig := &api.BuildConfig{
 		JSONBase: kubeapi.JSONBase{ID: ""},
 		DesiredInput: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://github.com/my/repository",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
 		},
 	}
 	if result := ValidateBuildConfig(buildConfig); len(result) != 1 {
@@ -65,35 +67,301 @@ func TestBuildConfigValidationFailure(t *testing.T) {
 	}
 }
 
+func TestBuildConfigValidationNegativeMaxConcurrentBuilds(t *testing.T) {
+	buildConfig := &api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: "configId"},
+		DesiredInput: api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
+		},
+		MaxConcurrentBuilds: -1,
+	}
+	if result := ValidateBuildConfig(buildConfig); len(result) != 1 {
+		t.Errorf("Unexpected validation result %v", result)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	valid := []api.BuildCredentials{
+		{Source: &api.SourceCredentials{SSHPrivateKey: "key"}},
+		{Source: &api.SourceCredentials{Username: "user", Password: "secret"}},
+		{DockerConfig: `{"registry.example.com":{"auth":"dXNlcjpzZWNyZXQ=","email":"user@example.com"}}`},
+	}
+	for _, credentials := range valid {
+		if result := validateCredentials(&credentials); len(result) > 0 {
+			t.Errorf("Unexpected validation error returned for %#v: %v", credentials, result)
+		}
+	}
+
+	errorCases := map[string]api.BuildCredentials{
+		"empty source":         {Source: &api.SourceCredentials{}},
+		"key and basic auth":   {Source: &api.SourceCredentials{SSHPrivateKey: "key", Username: "user", Password: "secret"}},
+		"no password":          {Source: &api.SourceCredentials{Username: "user"}},
+		"invalid dockerConfig": {DockerConfig: "secret"},
+	}
+	for desc, credentials := range errorCases {
+		result := validateCredentials(&credentials)
+		if len(result) != 1 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+			continue
+		}
+		if strings.Contains(result[0].Error(), "secret") {
+			t.Errorf("%s: Validation error discloses a secret: %v", desc, result[0])
+		}
+	}
+}
+
+func TestBuildConfigValidationRetryPolicy(t *testing.T) {
+	buildConfig := &api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: "configId"},
+		DesiredInput: api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
+		},
+		RetryPolicy: &api.BuildRetryPolicy{MaxAttempts: 3, BackoffSeconds: 30, MaxBackoffSeconds: 600},
+	}
+	if result := ValidateBuildConfig(buildConfig); len(result) != 0 {
+		t.Errorf("Unexpected validation result %v", result)
+	}
+
+	errorCases := map[string]api.BuildRetryPolicy{
+		"No attempts":          {MaxAttempts: 0},
+		"Negative backoff":     {MaxAttempts: 3, BackoffSeconds: -1},
+		"Negative max backoff": {MaxAttempts: 3, MaxBackoffSeconds: -1},
+	}
+	for desc, policy := range errorCases {
+		buildConfig.RetryPolicy = &policy
+		if result := ValidateBuildConfig(buildConfig); len(result) != 1 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+		}
+	}
+}
+
+func TestBuildConfigValidationUpstream(t *testing.T) {
+	buildConfig := &api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: "configId"},
+		DesiredInput: api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+			ImageTag: "repository/data",
+		},
+		Upstream: []string{"base", "library"},
+	}
+	if result := ValidateBuildConfig(buildConfig); len(result) != 0 {
+		t.Errorf("Unexpected validation result %v", result)
+	}
+
+	errorCases := map[string][]string{
+		"Empty id":  {""},
+		"Self":      {"configId"},
+		"Duplicate": {"base", "base"},
+	}
+	for desc, upstream := range errorCases {
+		buildConfig.Upstream = upstream
+		if result := ValidateBuildConfig(buildConfig); len(result) != 1 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+		}
+	}
+}
+
+func TestValidateBuildConfigUpstream(t *testing.T) {
+	configs := []api.BuildConfig{
+		{JSONBase: kubeapi.JSONBase{ID: "base"}},
+		{JSONBase: kubeapi.JSONBase{ID: "library"}, Upstream: []string{"base"}},
+		{JSONBase: kubeapi.JSONBase{ID: "app"}, Upstream: []string{"library", "base"}},
+		{JSONBase: kubeapi.JSONBase{ID: "frontend"}, Upstream: []string{"app"}},
+	}
+
+	successCases := map[string]*api.BuildConfig{
+		"New config":          {JSONBase: kubeapi.JSONBase{ID: "tests"}, Upstream: []string{"app", "library"}},
+		"Unknown upstream":    {JSONBase: kubeapi.JSONBase{ID: "tests"}, Upstream: []string{"missing"}},
+		"Updated config":      {JSONBase: kubeapi.JSONBase{ID: "app"}, Upstream: []string{"base"}},
+		"Upstream removed":    {JSONBase: kubeapi.JSONBase{ID: "library"}},
+		"No upstream on base": {JSONBase: kubeapi.JSONBase{ID: "base"}},
+	}
+	for desc, config := range successCases {
+		if result := ValidateBuildConfigUpstream(config, configs); len(result) != 0 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+		}
+	}
+
+	errorCases := map[string]*api.BuildConfig{
+		"Direct cycle":   {JSONBase: kubeapi.JSONBase{ID: "base"}, Upstream: []string{"library"}},
+		"Indirect cycle": {JSONBase: kubeapi.JSONBase{ID: "base"}, Upstream: []string{"frontend"}},
+		"Added upstream": {JSONBase: kubeapi.JSONBase{ID: "library"}, Upstream: []string{"base", "frontend"}},
+	}
+	for desc, config := range errorCases {
+		result := ValidateBuildConfigUpstream(config, configs)
+		if len(result) != 1 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+		}
+	}
+
+	result := ValidateBuildConfigUpstream(errorCases["Indirect cycle"], configs)
+	if e, a := "base <- frontend <- app <- library <- base", result[0].(errs.ValidationError).BadValue; e != a {
+		t.Errorf("Expected the cycle %q to be reported, got %q", e, a)
+	}
+}
+
+func TestValidateBuildRequest(t *testing.T) {
+	stiInput := &api.BuildInput{
+		Type:         api.STIBuildType,
+		Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/my/repository"}},
+		ImageTag:     "repository/data",
+		BuilderImage: "builder/image",
+	}
+	request := &api.BuildRequest{
+		Ref:          "v1.2",
+		Env:          []kubeapi.EnvVar{{Name: "DEBUG", Value: "true"}},
+		BuilderImage: "builder/image:next",
+	}
+	if result := ValidateBuildRequest(request, stiInput); len(result) != 0 {
+		t.Errorf("Unexpected validation result %v", result)
+	}
+
+	dockerfileInput := &api.BuildInput{
+		Type:     api.DockerBuildType,
+		Source:   api.BuildSource{Type: api.DockerfileBuildSourceType, Dockerfile: "FROM busybox"},
+		ImageTag: "repository/data",
+	}
+	errorCases := map[string]struct {
+		request *api.BuildRequest
+		input   *api.BuildInput
+	}{
+		"Ref without git source":        {&api.BuildRequest{Ref: "v1.2"}, dockerfileInput},
+		"Invalid env":                   {&api.BuildRequest{Env: []kubeapi.EnvVar{{Name: "", Value: "true"}}}, stiInput},
+		"Reserved env":                  {&api.BuildRequest{Env: []kubeapi.EnvVar{{Name: "BUILD_RESULT_FILE", Value: "/tmp/result"}}}, stiInput},
+		"Builder image of docker build": {&api.BuildRequest{BuilderImage: "builder/image"}, dockerfileInput},
+	}
+	for desc, c := range errorCases {
+		if result := ValidateBuildRequest(c.request, c.input); len(result) != 1 {
+			t.Errorf("%s: Unexpected validation result %v", desc, result)
+		}
+	}
+}
+
+func TestValidateCustomBuildInput(t *testing.T) {
+	input := &api.BuildInput{
+		Type:     api.CustomBuildType,
+		Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+		ImageTag: "repository/data",
+		Custom: &api.CustomBuildInput{
+			Image:              "builder/image",
+			Env:                []kubeapi.EnvVar{{Name: "GRADLE_TASKS", Value: "clean install"}},
+			ExposeDockerSocket: true,
+		},
+	}
+	if result := validateBuildInput(input); len(result) > 0 {
+		t.Errorf("Unexpected validation error returned %v", result)
+	}
+}
+
 func TestValidateBuildInput(t *testing.T) {
 	errorCases := map[string]*api.BuildInput{
 		"No source URI": &api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: ""}},
+			ImageTag: "repository/data",
 		},
 		"Invalid source URI": &api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "::",
-			ImageTag:  "repository/data",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "::"}},
+			ImageTag: "repository/data",
 		},
 		"No image tag": &api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://github.com/test/uri",
-			ImageTag:  "",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "",
 		},
 		"No builder image with STIBuildType": &api.BuildInput{
 			Type:         api.STIBuildType,
-			SourceURI:    "http://github.com/test/uri",
+			Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
 			ImageTag:     "repository/data",
 			BuilderImage: "",
 		},
 		"Builder image with DockerBuildType": &api.BuildInput{
 			Type:         api.DockerBuildType,
-			SourceURI:    "http://github.com/test/uri",
+			Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
 			ImageTag:     "repository/data",
 			BuilderImage: "builder/image",
 		},
+		"Base image with STIBuildType": &api.BuildInput{
+			Type:         api.STIBuildType,
+			Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag:     "repository/data",
+			BuilderImage: "builder/image",
+			BaseImage:    "base/image",
+		},
+		"No custom input with CustomBuildType": &api.BuildInput{
+			Type:     api.CustomBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+		},
+		"No image in custom input": &api.BuildInput{
+			Type:     api.CustomBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Custom:   &api.CustomBuildInput{},
+		},
+		"Invalid env var in custom input": &api.BuildInput{
+			Type:     api.CustomBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Custom: &api.CustomBuildInput{
+				Image: "builder/image",
+				Env:   []kubeapi.EnvVar{{Name: "1NVALID", Value: "value"}},
+			},
+		},
+		"Custom input with DockerBuildType": &api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Custom:   &api.CustomBuildInput{Image: "builder/image"},
+		},
+		"Negative memory limit": &api.BuildInput{
+			Type:       api.DockerBuildType,
+			Source:     api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag:   "repository/data",
+			PodOptions: &api.BuildPodOptions{Memory: -1},
+		},
+		"Reserved pod label": &api.BuildInput{
+			Type:       api.DockerBuildType,
+			Source:     api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag:   "repository/data",
+			PodOptions: &api.BuildPodOptions{Labels: map[string]string{api.BuildLabel: "other"}},
+		},
+		"Empty minion": &api.BuildInput{
+			Type:       api.DockerBuildType,
+			Source:     api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag:   "repository/data",
+			PodOptions: &api.BuildPodOptions{Minions: []string{"build1", ""}},
+		},
+		"Strategy env var": &api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Env:      []kubeapi.EnvVar{{Name: "CREDENTIALS_URL", Value: "http://example.com"}},
+		},
+		"Reserved env var": &api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Env:      []kubeapi.EnvVar{{Name: "PATH", Value: "/opt/bin"}},
+		},
+		"Negative timeout": &api.BuildInput{
+			Type:           api.DockerBuildType,
+			Source:         api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag:       "repository/data",
+			TimeoutSeconds: -1,
+		},
+		"Invalid env var name": &api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/uri"}},
+			ImageTag: "repository/data",
+			Env:      []kubeapi.EnvVar{{Name: "MAVEN-MIRROR", Value: "http://mirror.example.com"}},
+		},
 	}
 
 	for desc, config := range errorCases {
@@ -104,3 +372,57 @@ func TestValidateBuildInput(t *testing.T) {
 		// TODO: Verify we got the right type of validation error.
 	}
 }
+
+func TestValidateSource(t *testing.T) {
+	validSources := map[string]api.BuildSource{
+		"git": {
+			Type: api.GitBuildSourceType,
+			Git:  &api.GitBuildSource{URI: "git://github.com/my/monorepo", Ref: "v1", ContextDir: "services/web"},
+		},
+		"dockerfile": {
+			Type:       api.DockerfileBuildSourceType,
+			Dockerfile: "FROM busybox",
+		},
+		"archive": {
+			Type:    api.ArchiveBuildSourceType,
+			Archive: &api.ArchiveBuildSource{URL: "https://example.com/app.tar.gz"},
+		},
+	}
+	for desc, source := range validSources {
+		if errors := validateSource(&source); len(errors) > 0 {
+			t.Errorf("%s: Unexpected validation error returned %v", desc, errors)
+		}
+	}
+
+	errorCases := map[string]api.BuildSource{
+		"No type":          {},
+		"Unsupported type": {Type: "svn"},
+		"No git source":    {Type: api.GitBuildSourceType},
+		"Absolute context dir": {
+			Type: api.GitBuildSourceType,
+			Git:  &api.GitBuildSource{URI: "http://github.com/test/uri", ContextDir: "/etc"},
+		},
+		"Context dir outside of the source": {
+			Type: api.GitBuildSourceType,
+			Git:  &api.GitBuildSource{URI: "http://github.com/test/uri", ContextDir: "services/../../etc"},
+		},
+		"Empty dockerfile":  {Type: api.DockerfileBuildSourceType},
+		"No archive source": {Type: api.ArchiveBuildSourceType},
+		"Archive not served over http": {
+			Type:    api.ArchiveBuildSourceType,
+			Archive: &api.ArchiveBuildSource{URL: "ftp://example.com/app.tar.gz"},
+		},
+		"Git member in a dockerfile source": {
+			Type:       api.DockerfileBuildSourceType,
+			Dockerfile: "FROM busybox",
+			Git:        &api.GitBuildSource{URI: "http://github.com/test/uri"},
+		},
+	}
+	for desc, source := range errorCases {
+		errors := validateSource(&source)
+		if len(errors) != 1 {
+			t.Errorf("%s: Unexpected validation result: %v", desc, errors)
+		}
+		// TODO: Verify we got the right type of validation error.
+	}
+}
@@ -0,0 +1,3 @@
+// Package archive stores the source archives uploaded to start builds until the
+// builder pods have downloaded them and the builds have finished.
+package archive
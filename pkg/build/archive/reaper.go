@@ -0,0 +1,101 @@
+package archive
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// BuildLister lists the builds archives are kept for.
+type BuildLister interface {
+	ListBuilds(labels labels.Selector) (*api.BuildList, error)
+}
+
+// Reaper removes the archives of builds which completed, the archives of builds
+// which ended otherwise once they are no longer retained, and the archives of
+// builds which do not exist.
+type Reaper struct {
+	store     *Store
+	builds    BuildLister
+	grace     time.Duration
+	retention time.Duration
+}
+
+// NewReaper creates a new Reaper. An archive is stored before its build is
+// created, so archives without a build are only removed once they are older
+// than grace, which also applies to incomplete uploads. The archives of builds
+// which failed, errored or were cancelled are kept for retention after the
+// build ended, so that the build can be re-run by cloning it.
+func NewReaper(store *Store, builds BuildLister, grace, retention time.Duration) *Reaper {
+	return &Reaper{
+		store:     store,
+		builds:    builds,
+		grace:     grace,
+		retention: retention,
+	}
+}
+
+// Run removes archives immediately and then every period, until stop is closed.
+func (r *Reaper) Run(period time.Duration, stop <-chan struct{}) {
+	go buildutil.Until(r.Reap, period, stop)
+}
+
+// Reap removes the archives which are no longer needed.
+func (r *Reaper) Reap() {
+	entries, err := r.store.List()
+	if err != nil {
+		glog.Errorf("Error listing build archives: %v", err)
+		return
+	}
+	builds, err := r.builds.ListBuilds(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error listing builds: %v", err)
+		return
+	}
+	buildsByID := make(map[string]*api.Build, len(builds.Items))
+	for i := range builds.Items {
+		buildsByID[builds.Items[i].ID] = &builds.Items[i]
+	}
+
+	for _, entry := range entries {
+		expired := time.Since(entry.ModTime) > r.grace
+		if strings.HasPrefix(entry.ID, uploadPrefix) {
+			if expired {
+				glog.Infof("Removing incomplete build archive %s", entry.ID)
+				if err := r.store.Discard(entry.ID); err != nil {
+					glog.Errorf("Error removing incomplete build archive %s: %v", entry.ID, err)
+				}
+			}
+			continue
+		}
+
+		build, exists := buildsByID[entry.ID]
+		switch {
+		case exists && !buildutil.IsBuildComplete(build):
+			continue
+		case exists && build.Status != api.BuildComplete && !r.retentionElapsed(build, entry):
+			continue
+		case !exists && !expired:
+			continue
+		}
+		glog.Infof("Removing the archive of build %s", entry.ID)
+		if err := r.store.Remove(entry.ID); err != nil {
+			glog.Errorf("Error removing the archive of build %s: %v", entry.ID, err)
+		}
+	}
+}
+
+// retentionElapsed returns true if the build ended longer than the retention
+// ago. A build which did not record when it ended is considered to have ended
+// when its archive was stored.
+func (r *Reaper) retentionElapsed(build *api.Build, entry Entry) bool {
+	ended := build.CompletionTimestamp.Time
+	if ended.IsZero() {
+		ended = entry.ModTime
+	}
+	return time.Since(ended) > r.retention
+}
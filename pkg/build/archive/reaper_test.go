@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+type buildLister struct {
+	builds []api.Build
+}
+
+func (l *buildLister) ListBuilds(labels labels.Selector) (*api.BuildList, error) {
+	return &api.BuildList{Items: l.builds}, nil
+}
+
+func saveArchive(t *testing.T, store *Store, id string, age time.Duration) {
+	if _, err := store.Save(id, strings.NewReader("archive")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(filepath.Join(store.dir, id), modTime, modTime); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestReap(t *testing.T) {
+	store, cleanup := NewTestStore(t, 16)
+	defer cleanup()
+
+	saveArchive(t, store, "running", 2*time.Hour)
+	saveArchive(t, store, "complete", time.Minute)
+	saveArchive(t, store, "failed", time.Minute)
+	saveArchive(t, store, "failed-long-ago", 48*time.Hour)
+	saveArchive(t, store, "cancelled", 48*time.Hour)
+	saveArchive(t, store, "uploading", time.Minute)
+	saveArchive(t, store, "deleted", 2*time.Hour)
+	builds := &buildLister{[]api.Build{
+		{JSONBase: kubeapi.JSONBase{ID: "running"}, Status: api.BuildRunning},
+		{JSONBase: kubeapi.JSONBase{ID: "complete"}, Status: api.BuildComplete},
+		{JSONBase: kubeapi.JSONBase{ID: "failed"}, Status: api.BuildFailed, CompletionTimestamp: util.Now()},
+		{JSONBase: kubeapi.JSONBase{ID: "failed-long-ago"}, Status: api.BuildFailed, CompletionTimestamp: util.Time{Time: time.Now().Add(-25 * time.Hour)}},
+		{JSONBase: kubeapi.JSONBase{ID: "cancelled"}, Status: api.BuildCancelled},
+	}}
+
+	NewReaper(store, builds, time.Hour, 24*time.Hour).Reap()
+
+	for id, kept := range map[string]bool{
+		"running":         true,
+		"complete":        false,
+		"failed":          true,
+		"failed-long-ago": false,
+		"cancelled":       false,
+		"uploading":       true,
+		"deleted":         false,
+	} {
+		_, err := store.Open(id)
+		if kept && err != nil {
+			t.Errorf("Expected archive %s to be kept, got %v", id, err)
+		}
+		if !kept && !os.IsNotExist(err) {
+			t.Errorf("Expected archive %s to be removed, got %v", id, err)
+		}
+	}
+}
+
+func TestReapIncompleteUploads(t *testing.T) {
+	store, cleanup := NewTestStore(t, 16)
+	defer cleanup()
+
+	for id, age := range map[string]time.Duration{".upload-old": 2 * time.Hour, ".upload-new": time.Minute} {
+		path := filepath.Join(store.dir, id)
+		if err := ioutil.WriteFile(path, []byte("arch"), 0600); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		modTime := time.Now().Add(-age)
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	NewReaper(store, &buildLister{}, time.Hour, time.Hour).Reap()
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != ".upload-new" {
+		t.Errorf("Expected only the recent upload to be kept, got %#v", entries)
+	}
+}
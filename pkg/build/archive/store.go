@@ -0,0 +1,179 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrTooLarge is returned when an archive exceeds the size limit of the store.
+var ErrTooLarge = errors.New("the archive exceeds the maximum size")
+
+// uploadPrefix prefixes the temporary files archives are written to until
+// they are complete.
+const uploadPrefix = ".upload-"
+
+// Store keeps archives on the local disk of the master, named by the id of the
+// build they are the source of.
+type Store struct {
+	dir     string
+	maxSize int64
+}
+
+// Entry describes an archive kept by a Store.
+type Entry struct {
+	ID      string
+	ModTime time.Time
+}
+
+// NewStore creates a Store keeping archives of at most maxSize bytes in dir,
+// which is created if it does not exist.
+func NewStore(dir string, maxSize int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, maxSize: maxSize}, nil
+}
+
+// MaxSize returns the maximum size of an archive in bytes.
+func (s *Store) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *Store) path(id string) (string, error) {
+	if len(id) == 0 || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid archive id %q", id)
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// Save streams the archive read from r into the store and returns its size. An
+// archive larger than the maximum size is discarded and ErrTooLarge returned.
+// The archive is written to a temporary file first, so that an incomplete
+// archive is never served.
+func (s *Store) Save(id string, r io.Reader) (int64, error) {
+	if _, err := s.path(id); err != nil {
+		return 0, err
+	}
+	upload, size, err := s.Stage(r)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Commit(upload, id); err != nil {
+		s.Discard(upload)
+		return 0, err
+	}
+	return size, nil
+}
+
+// Stage streams the archive read from r into a temporary file of the store and
+// returns the id of the upload and the size of the archive. The archive is only
+// served once Commit names it after its build. An archive larger than the
+// maximum size is discarded and ErrTooLarge returned.
+func (s *Store) Stage(r io.Reader) (string, int64, error) {
+	file, err := ioutil.TempFile(s.dir, uploadPrefix)
+	if err != nil {
+		return "", 0, err
+	}
+	size, err := io.Copy(file, io.LimitReader(r, s.maxSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > s.maxSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, err
+	}
+	return filepath.Base(file.Name()), size, nil
+}
+
+// Commit names the archive of the upload returned by Stage after the build id.
+func (s *Store) Commit(upload, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	uploadPath, err := s.uploadPath(upload)
+	if err != nil {
+		return err
+	}
+	return os.Rename(uploadPath, path)
+}
+
+// Discard deletes the archive of an upload returned by Stage which is not
+// committed.
+func (s *Store) Discard(upload string) error {
+	path, err := s.uploadPath(upload)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Link makes the archive identified by id also the archive of the build
+// identified by to, for a build which is a copy of the build id. The archive is
+// hard linked, so that it is kept until both builds are complete. It returns an
+// error satisfying os.IsNotExist if the archive id does not exist.
+func (s *Store) Link(id, to string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	toPath, err := s.path(to)
+	if err != nil {
+		return err
+	}
+	return os.Link(path, toPath)
+}
+
+// Open opens the archive identified by id for reading.
+func (s *Store) Open(id string) (*os.File, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Remove deletes the archive identified by id, if it exists.
+func (s *Store) Remove(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns the archives kept by the store, including the incomplete
+// archives which are still being written, whose ids start with a dot.
+func (s *Store) List() ([]Entry, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		entries = append(entries, Entry{ID: info.Name(), ModTime: info.ModTime()})
+	}
+	return entries, nil
+}
+
+func (s *Store) uploadPath(upload string) (string, error) {
+	if !strings.HasPrefix(upload, uploadPrefix) || strings.ContainsAny(upload, `/\`) {
+		return "", fmt.Errorf("invalid upload id %q", upload)
+	}
+	return filepath.Join(s.dir, upload), nil
+}
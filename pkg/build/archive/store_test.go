@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStoreSaveAndOpen(t *testing.T) {
+	store, cleanup := NewTestStore(t, 16)
+	defer cleanup()
+
+	size, err := store.Save("build-1", strings.NewReader("archive content"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if size != 15 {
+		t.Errorf("Expected a size of 15 bytes, got %d", size)
+	}
+	file, err := store.Open("build-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer file.Close()
+	content, _ := ioutil.ReadAll(file)
+	if string(content) != "archive content" {
+		t.Errorf("Unexpected content %q", content)
+	}
+}
+
+func TestStoreSaveTooLarge(t *testing.T) {
+	store, cleanup := NewTestStore(t, 8)
+	defer cleanup()
+
+	if _, err := store.Save("build-1", strings.NewReader("archive content")); err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+	if _, err := store.Open("build-1"); !os.IsNotExist(err) {
+		t.Errorf("Expected the archive not to be stored, got %v", err)
+	}
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected the incomplete upload to be removed, got %#v", entries)
+	}
+}
+
+func TestStoreInvalidID(t *testing.T) {
+	store, cleanup := NewTestStore(t, 8)
+	defer cleanup()
+
+	for _, id := range []string{"", "../build-1", "builds/build-1", ".upload-1"} {
+		if _, err := store.Save(id, strings.NewReader("archive")); err == nil {
+			t.Errorf("Expected an error for id %q", id)
+		}
+		if _, err := store.Open(id); err == nil || os.IsNotExist(err) {
+			t.Errorf("Expected an invalid id error for id %q, got %v", id, err)
+		}
+	}
+}
+
+func TestStoreRemove(t *testing.T) {
+	store, cleanup := NewTestStore(t, 8)
+	defer cleanup()
+
+	if _, err := store.Save("build-1", strings.NewReader("archive")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Remove("build-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.Open("build-1"); !os.IsNotExist(err) {
+		t.Errorf("Expected the archive to be removed, got %v", err)
+	}
+	if err := store.Remove("build-1"); err != nil {
+		t.Errorf("Expected removing a missing archive to succeed, got %v", err)
+	}
+}
+
+func TestStoreStageAndCommit(t *testing.T) {
+	store, cleanup := NewTestStore(t, 16)
+	defer cleanup()
+
+	upload, size, err := store.Stage(strings.NewReader("archive"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if size != 7 || !strings.HasPrefix(upload, uploadPrefix) {
+		t.Errorf("Unexpected upload %s of %d bytes", upload, size)
+	}
+	if err := store.Commit(upload, "build-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file, err := store.Open("build-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file.Close()
+
+	upload, _, err = store.Stage(strings.NewReader("discarded"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Discard(upload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Discard("build-1"); err == nil {
+		t.Errorf("Expected a committed archive not to be discarded")
+	}
+	entries, _ := store.List()
+	if len(entries) != 1 || entries[0].ID != "build-1" {
+		t.Errorf("Expected only the committed archive to be kept, got %#v", entries)
+	}
+}
+
+func TestStoreLink(t *testing.T) {
+	store, cleanup := NewTestStore(t, 16)
+	defer cleanup()
+
+	if _, err := store.Save("build-1", strings.NewReader("archive")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Link("build-1", "build-2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Remove("build-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file, err := store.Open("build-2")
+	if err != nil {
+		t.Fatalf("Expected the linked archive to outlive the original, got %v", err)
+	}
+	defer file.Close()
+	content, _ := ioutil.ReadAll(file)
+	if string(content) != "archive" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	if err := store.Link("build-1", "build-3"); !os.IsNotExist(err) {
+		t.Errorf("Expected the missing archive to be reported, got %v", err)
+	}
+}
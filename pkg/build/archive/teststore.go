@@ -0,0 +1,27 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// Fataler reports a fatal error of a test, as *testing.T does.
+type Fataler interface {
+	Fatalf(format string, args ...interface{})
+}
+
+// NewTestStore creates a Store keeping archives of at most maxSize bytes in a
+// new temporary directory, for tests. The returned function removes the
+// directory.
+func NewTestStore(t Fataler, maxSize int64) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "archive-store")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	store, err := NewStore(dir, maxSize)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return store, func() { os.RemoveAll(dir) }
+}
@@ -2,152 +2,612 @@ package build
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/golang/glog"
 	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
+	buildutil "github.com/openshift/origin/pkg/build/util"
 	osclient "github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 // BuildJobStrategy represents a strategy for executing a build by
 // creating a pod definition that will execute the build
 type BuildJobStrategy interface {
-	CreateBuildPod(build *api.Build, dockerImage string) *kubeapi.Pod
+	CreateBuildPod(build *api.Build, dockerImage string, credentials *strategy.CredentialsSource) (*kubeapi.Pod, error)
+}
+
+// BuildConfigGetter retrieves build configurations together with their
+// credentials, which are never returned by the API
+type BuildConfigGetter interface {
+	GetBuildConfig(id string) (*api.BuildConfig, error)
 }
 
 // BuildController watches build resources and manages their state
 type BuildController struct {
-	osClient        osclient.Interface
-	kubeClient      kubeclient.Interface
-	buildStrategies map[api.BuildType]BuildJobStrategy
-	dockerRegistry  string
-	timeout         int
+	osClient            osclient.Interface
+	kubeClient          kubeclient.Interface
+	buildStrategies     map[api.BuildType]BuildJobStrategy
+	dockerRegistry      string
+	timeout             int
+	maxConcurrentBuilds int
+	resultReader        BuildResultReader
+	configs             BuildConfigGetter
+	credentials         *CredentialsIssuer
+	syncTime            <-chan time.Time
+	pollTime            <-chan time.Time
+	// queue holds the queue positions of the waiting builds being synced
+	queue map[string]int
 }
 
-// NewBuildController creates a new build controller
+// NewBuildController creates a new build controller. maxConcurrentBuilds limits
+// the number of builds which are pending or running at the same time, further
+// builds are queued. 0 means no limit. The image reported by resultReader for
+// every completed build is recorded in the image registry, a nil resultReader
+// disables recording. Builds whose build configuration has credentials, as read
+// from configs, fetch them with a token issued by credentials. A nil configs
+// builds without credentials.
 func NewBuildController(kc kubeclient.Interface,
 	oc osclient.Interface,
 	strategies map[api.BuildType]BuildJobStrategy,
 	registry string,
-	timeout int) *BuildController {
+	timeout int,
+	maxConcurrentBuilds int,
+	resultReader BuildResultReader,
+	configs BuildConfigGetter,
+	credentials *CredentialsIssuer) *BuildController {
 
-	glog.Infof("Creating build controller with dockerRegistry=%s, timeout=%d",
-		registry, timeout)
+	glog.Infof("Creating build controller with dockerRegistry=%s, timeout=%d, maxConcurrentBuilds=%d",
+		registry, timeout, maxConcurrentBuilds)
 
 	bc := &BuildController{
-		kubeClient:      kc,
-		osClient:        oc,
-		buildStrategies: strategies,
-		dockerRegistry:  registry,
-		timeout:         timeout,
+		kubeClient:          kc,
+		osClient:            oc,
+		buildStrategies:     strategies,
+		dockerRegistry:      registry,
+		timeout:             timeout,
+		maxConcurrentBuilds: maxConcurrentBuilds,
+		resultReader:        resultReader,
+		configs:             configs,
+		credentials:         credentials,
 	}
 	return bc
 
 }
 
-// Run begins watching and syncing build jobs onto the cluster.
-func (bc *BuildController) Run(period time.Duration) {
-	syncTime := time.Tick(period)
-	go util.Forever(func() { bc.watchBuilds(syncTime) }, period)
+// Run begins watching and syncing build jobs onto the cluster. The builds which
+// have not reached a terminal status are resynced every resyncPeriod, as a
+// fallback for changes the watch may have missed. The running builds are synced
+// every pollPeriod, which is how they notice that their pods have terminated.
+// The controller stops once stop is closed.
+func (bc *BuildController) Run(resyncPeriod, pollPeriod time.Duration, stop <-chan struct{}) {
+	syncTicker := time.NewTicker(resyncPeriod)
+	pollTicker := time.NewTicker(pollPeriod)
+	bc.syncTime = syncTicker.C
+	bc.pollTime = pollTicker.C
+	resourceVersion := uint64(0)
+	go func() {
+		buildutil.Until(func() { bc.watchBuilds(&resourceVersion, stop) }, pollPeriod, stop)
+		syncTicker.Stop()
+		pollTicker.Stop()
+	}()
 }
 
-// The main sync loop. Iterates over current builds and delegates syncing.
-func (bc *BuildController) watchBuilds(syncTime <-chan time.Time) {
+// The main sync loop. Reacts to build changes as they are observed and
+// periodically resyncs builds which have not yet reached a terminal status.
+// resourceVersion is a pointer to the resource version to use/update. It returns
+// once stop is closed.
+func (bc *BuildController) watchBuilds(resourceVersion *uint64, stop <-chan struct{}) {
+	watching, err := bc.osClient.WatchBuilds(
+		labels.Everything(),
+		labels.Everything(),
+		*resourceVersion,
+	)
+	if err != nil {
+		glog.Errorf("Unexpected failure to watch builds: %v", err)
+		time.Sleep(5 * time.Second)
+		return
+	}
+
 	for {
 		select {
-		case <-syncTime:
-			builds, err := bc.osClient.ListBuilds(labels.Everything())
-			if err != nil {
-				glog.Errorf("Error listing builds: %v (%#v)", err, err)
+		case <-stop:
+			watching.Stop()
+			return
+		case <-bc.syncTime:
+			bc.synchronizeAll()
+		case <-bc.pollTime:
+			bc.synchronizeRunning()
+		case event, open := <-watching.ResultChan():
+			if !open {
+				// The watch channel has been closed, or something else went
+				// wrong with our etcd watch call. Let the buildutil.Until()
+				// that called us call us again.
 				return
 			}
-			for _, build := range builds.Items {
-				nextStatus, err := bc.synchronize(&build)
-				if err != nil {
-					glog.Errorf("Error synchronizing build ID %v: %#v", build.ID, err)
-				}
-
-				if nextStatus != build.Status {
-					build.Status = nextStatus
-					if _, err := bc.osClient.UpdateBuild(&build); err != nil {
-						glog.Errorf("Error updating build ID %v to status %v: %#v", build.ID, nextStatus, err)
-					}
-				}
+			build, ok := event.Object.(*api.Build)
+			if !ok {
+				glog.Errorf("Unexpected object during build watch: %#v", event.Object)
+				continue
+			}
+			// If we get disconnected, start where we left off.
+			*resourceVersion = build.ResourceVersion + 1
+			if event.Type == watch.Deleted || buildutil.IsBuildComplete(build) {
+				continue
+			}
+			if build.Status == api.BuildQueued && !build.Cancelled {
+				// queued builds, which are modified whenever their queue position
+				// changes, are admitted by synchronizeWaiting once a slot is freed
+				continue
+			}
+			bc.syncBuild(build)
+			if buildutil.IsBuildComplete(build) {
+				// a build slot has been freed, hand it to the next queued build
+				bc.synchronizeWaiting()
+			}
+		}
+	}
+}
+
+// synchronizeAll lists the builds which have not reached a terminal status and
+// syncs them. It is a fallback for changes the watch may have missed. It also
+// retries triggering the builds downstream of complete builds.
+func (bc *BuildController) synchronizeAll() {
+	defer observeSince(buildSyncDuration, time.Now(), "all")
+	builds, err := bc.osClient.ListBuilds(buildutil.ActiveBuildsSelector())
+	if err != nil {
+		glog.Errorf("Error listing builds: %v (%#v)", err, err)
+		return
+	}
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if !isWaiting(build) {
+			bc.syncBuild(build)
+		}
+	}
+	// builds waiting for a build slot are synced last, so that they can take
+	// the slots of builds which have just finished
+	bc.syncWaiting(builds.Items)
+
+	pending, err := bc.osClient.ListBuilds(buildutil.DownstreamPendingSelector())
+	if err != nil {
+		glog.Errorf("Error listing builds with pending downstream builds: %v (%#v)", err, err)
+		return
+	}
+	for i := range pending.Items {
+		bc.triggerDownstreamBuilds(&pending.Items[i])
+	}
+}
+
+// synchronizeRunning syncs the running builds, which complete once their pods
+// have terminated.
+func (bc *BuildController) synchronizeRunning() {
+	defer observeSince(buildSyncDuration, time.Now(), "running")
+	builds, err := bc.osClient.ListBuilds(buildutil.StatusSelector(api.BuildRunning))
+	if err != nil {
+		glog.Errorf("Error listing running builds: %v (%#v)", err, err)
+		return
+	}
+	completed := false
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		bc.syncBuild(build)
+		completed = completed || buildutil.IsBuildComplete(build)
+	}
+	if completed {
+		// build slots have been freed, hand them to the next queued builds
+		bc.synchronizeWaiting()
+	}
+}
+
+// synchronizeWaiting syncs the builds waiting for a build slot in FIFO order.
+func (bc *BuildController) synchronizeWaiting() {
+	builds, err := bc.osClient.ListBuilds(buildutil.ActiveBuildsSelector())
+	if err != nil {
+		glog.Errorf("Error listing builds: %v (%#v)", err, err)
+		return
+	}
+	bc.syncWaiting(builds.Items)
+}
+
+// syncBuild synchronizes a single build and persists it if it changed. A build
+// modified concurrently is read again and synchronized from its current version.
+func (bc *BuildController) syncBuild(build *api.Build) {
+	defer observeSince(buildSyncDuration, time.Now(), "build")
+	for retries := 0; ; retries++ {
+		err := bc.syncBuildOnce(build)
+		if err == nil {
+			return
+		}
+		if !buildutil.IsConflict(err) || retries >= buildutil.MaxConflictRetries {
+			glog.Errorf("Error updating build ID %v to status %v: %#v", build.ID, build.Status, err)
+			return
+		}
+		// the build was modified since it was read, synchronize its current version
+		current, err := bc.osClient.GetBuild(build.ID)
+		if err != nil {
+			glog.Errorf("Error reading build ID %v after an update conflict: %#v", build.ID, err)
+			return
+		}
+		*build = *current
+	}
+}
+
+// syncBuildOnce synchronizes build with its pod and stores the changes. The
+// returned error is the error of the update, which is a conflict if the build
+// has been modified since it was read.
+func (bc *BuildController) syncBuildOnce(build *api.Build) error {
+	if buildutil.IsBuildComplete(build) {
+		return nil
+	}
+
+	original := *build
+	nextStatus, err := bc.synchronize(build)
+	if err != nil {
+		glog.Errorf("Error synchronizing build ID %v: %#v", build.ID, err)
+	}
+
+	if nextStatus == api.BuildError {
+		nextStatus = bc.retry(build)
+	}
+
+	if nextStatus != build.Status {
+		recordTransition(build, &original, nextStatus)
+		build.Status = nextStatus
+		if buildutil.IsBuildComplete(build) {
+			build.CompletionTimestamp = util.Now()
+			if !build.StartTimestamp.IsZero() {
+				build.Duration = build.CompletionTimestamp.Sub(build.StartTimestamp.Time)
 			}
 		}
+		// the downstream builds are triggered after the build is stored, the
+		// flag is stored with the status so that a failed trigger is retried
+		if build.Status == api.BuildComplete && build.Config != nil {
+			build.DownstreamPending = true
+		}
+	}
+
+	if reflect.DeepEqual(original, *build) {
+		return nil
+	}
+	if _, err := bc.osClient.UpdateBuild(build); err != nil {
+		return err
+	}
+	recordTransitionMetrics(&original, build)
+	bc.triggerDownstreamBuilds(build)
+	return nil
+}
 
+// recordTransition appends the transition of build to nextStatus to its status
+// history. The message is only recorded if it was set by this transition.
+func recordTransition(build, original *api.Build, nextStatus api.BuildStatus) {
+	transition := api.BuildStatusTransition{
+		From:      build.Status,
+		To:        nextStatus,
+		Timestamp: util.Now(),
+	}
+	if build.Message != original.Message {
+		transition.Message = build.Message
 	}
+	build.StatusHistory = append(build.StatusHistory, transition)
 }
 
 func hasTimeoutElapsed(build *api.Build, timeout int) bool {
-	timestamp := build.CreationTimestamp
-	elapsed := time.Since(timestamp.Time)
+	if build.StartTimestamp.IsZero() {
+		return false
+	}
+	elapsed := time.Since(build.StartTimestamp.Time)
 	return int(elapsed.Seconds()) > timeout
 }
 
+// buildTimeout returns the timeout in seconds of the build, which may override
+// the default timeout of the controller.
+func (bc *BuildController) buildTimeout(build *api.Build) int {
+	if build.Input.TimeoutSeconds > 0 {
+		return build.Input.TimeoutSeconds
+	}
+	return bc.timeout
+}
+
+// configCredentials returns the credentials of the configuration which created
+// the build, or nil if the build has none.
+func configCredentials(configs BuildConfigGetter, build *api.Build) (*api.BuildCredentials, error) {
+	if build.Config == nil || configs == nil {
+		return nil, nil
+	}
+	config, err := configs.GetBuildConfig(build.Config.ID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Error retrieving credentials for build ID %v: %v", build.ID, err)
+	}
+	return config.Credentials, nil
+}
+
+// issueCredentials returns where the builder of the build fetches the
+// credentials of its build configuration, or nil if there are none.
+func (bc *BuildController) issueCredentials(build *api.Build) (*strategy.CredentialsSource, error) {
+	credentials, err := configCredentials(bc.configs, build)
+	if err != nil || credentials == nil {
+		return nil, err
+	}
+	if bc.credentials == nil {
+		return nil, fmt.Errorf("Build ID %v has credentials, but no credentials issuer is configured", build.ID)
+	}
+	return bc.credentials.Issue(build)
+}
+
+// outputImageRepository returns the Docker image repository in dockerRegistry
+// and the tag which the output of a build with imageTag is pushed to.
+func outputImageRepository(dockerRegistry, imageTag string) (string, string) {
+	repository, tag := parseImageReference(imageTag)
+	if len(dockerRegistry) > 0 {
+		repository = dockerRegistry + "/" + repository
+	}
+	return repository, tag
+}
+
+// buildPodID returns the id of the pod which executes the build.
+func buildPodID(build *api.Build) string {
+	return "build-" + string(build.Input.Type) + "-" + build.ID // TODO: better naming
+}
+
+// isBuildPodID returns true if podID is the id buildPodID gives to the pod of
+// the build buildID, whatever the type of the build.
+func isBuildPodID(podID, buildID string) bool {
+	buildType := strings.TrimSuffix(strings.TrimPrefix(podID, "build-"), "-"+buildID)
+	return len(buildType) > 0 && podID == "build-"+buildType+"-"+buildID
+}
+
+// podStartTime returns the time at which the first container of the pod started,
+// or the current time if the pod does not report it.
+func podStartTime(pod *kubeapi.Pod) util.Time {
+	var started time.Time
+	for _, info := range pod.CurrentState.Info {
+		if s := info.State.StartedAt; !s.IsZero() && (started.IsZero() || s.Before(started)) {
+			started = s
+		}
+	}
+	if started.IsZero() {
+		return util.Now()
+	}
+	return util.Time{Time: started}
+}
+
 // Determine the next status of a build given its current state and the state
 // of its associated pod.
 // TODO: improve handling of illegal state transitions
 func (bc *BuildController) synchronize(build *api.Build) (api.BuildStatus, error) {
 	glog.Infof("Syncing build %s", build.ID)
 
+	if build.Cancelled && !buildutil.IsBuildComplete(build) {
+		return bc.cancel(build)
+	}
+
 	switch build.Status {
-	case api.BuildNew:
-		build.PodID = "build-" + string(build.Input.Type) + "-" + build.ID // TODO: better naming
+	case api.BuildNew, api.BuildQueued:
+		position, err := bc.admit(build)
+		if err != nil {
+			return build.Status, err
+		}
+		build.QueuePosition = position
+		if position > 0 {
+			return api.BuildQueued, nil
+		}
+		build.PodID = buildPodID(build)
+		build.Attempt = 1
 		return api.BuildPending, nil
 	case api.BuildPending:
+		if time.Now().Before(build.RetryTimestamp.Time) {
+			return build.Status, nil // waiting for the backoff of a retry to elapse
+		}
 		buildStrategy, ok := bc.buildStrategies[build.Input.Type]
 		if !ok {
+			build.Reason = api.BuildReasonUnknownStrategy
+			build.Message = fmt.Sprintf("No build strategy is registered for build type %q", build.Input.Type)
 			return api.BuildError, fmt.Errorf("No build type for %s", build.Input.Type)
 		}
 
-		podSpec := buildStrategy.CreateBuildPod(build, bc.dockerRegistry)
+		credentials, err := bc.issueCredentials(build)
+		if err == ErrNoBuildPodAPIURL {
+			build.Message = fmt.Sprintf("Build pod could not be defined: %v", err)
+			return api.BuildError, err
+		}
+		if err != nil {
+			return build.Status, err
+		}
+
+		podSpec, err := buildStrategy.CreateBuildPod(build, bc.dockerRegistry, credentials)
+		if err != nil {
+			build.Message = fmt.Sprintf("Build pod could not be defined: %v", err)
+			return api.BuildError, err
+		}
 
-		glog.Infof("Attempting to create pod: %#v", podSpec)
-		_, err := bc.kubeClient.CreatePod(*podSpec)
+		glog.Infof("Attempting to create pod %s for build ID %v", podSpec.ID, build.ID)
+		_, err = bc.kubeClient.CreatePod(*podSpec)
 
-		// TODO: strongly typed error checking
+		if err != nil && isAlreadyExists(err) {
+			// the id of the pod is unique to the build, so the pod was created by
+			// an earlier sync whose update of the build did not succeed
+			glog.Infof("Pod %s of build ID %v already exists, the build is running", podSpec.ID, build.ID)
+			err = nil
+		}
 		if err != nil {
-			if strings.Index(err.Error(), "already exists") != -1 {
-				return build.Status, err // no transition, already handled by someone else
+			build.Message = fmt.Sprintf("Build pod %s could not be created: %v", podSpec.ID, err)
+			nextStatus := api.BuildError
+			build.Reason = api.BuildReasonPodCreationFailed
+			if isRejected(err) {
+				nextStatus = api.BuildFailed
+				build.Reason = api.BuildReasonPodCreationRejected
 			}
-
-			return api.BuildFailed, err
+			buildPodCreationErrors.Inc(string(build.Reason))
+			return nextStatus, err
 		}
 
+		// the message of a retried build describes the previous attempt
+		build.Reason = ""
+		build.Message = ""
 		return api.BuildRunning, nil
 	case api.BuildRunning:
-		if timedOut := hasTimeoutElapsed(build, bc.timeout); timedOut {
-			return api.BuildFailed, fmt.Errorf("Build timed out")
-		}
-
 		pod, err := bc.kubeClient.GetPod(build.PodID)
 		if err != nil {
+			if isNotFound(err) {
+				build.Reason = api.BuildReasonPodDeleted
+				build.Message = fmt.Sprintf("Build pod %s no longer exists", build.PodID)
+				return api.BuildError, err
+			}
 			return build.Status, fmt.Errorf("Error retrieving pod for build ID %v: %#v", build.ID, err)
 		}
 
+		if build.StartTimestamp.IsZero() &&
+			(pod.CurrentState.Status == kubeapi.PodRunning || pod.CurrentState.Status == kubeapi.PodTerminated) {
+			build.StartTimestamp = podStartTime(&pod)
+		}
+
 		// pod is still running
 		if pod.CurrentState.Status != kubeapi.PodTerminated {
+			timeout := bc.buildTimeout(build)
+			if timedOut := hasTimeoutElapsed(build, timeout); timedOut {
+				build.Reason = api.BuildReasonTimedOut
+				build.Message = fmt.Sprintf("Build did not finish within %d seconds", timeout)
+				return api.BuildFailed, fmt.Errorf("Build timed out")
+			}
 			return build.Status, nil
 		}
 
-		var nextStatus = api.BuildComplete
+		// the revision is recorded for failed builds too, so that they can be re-run
+		result, resultErr := bc.readBuildResult(build, &pod)
 
 		// check the exit codes of all the containers in the pod
-		for _, info := range pod.CurrentState.Info {
-			if info.State.ExitCode != 0 {
-				nextStatus = api.BuildFailed
-			}
+		if failures := containerFailures(&pod); len(failures) > 0 {
+			build.Reason = api.BuildReasonContainerFailed
+			build.Message = strings.Join(failures, "; ")
+			return api.BuildFailed, nil
 		}
-		return nextStatus, nil
-	case api.BuildComplete, api.BuildFailed, api.BuildError:
+		bc.recordBuildOutput(build, result, resultErr)
+		return api.BuildComplete, nil
+	case api.BuildComplete, api.BuildFailed, api.BuildError, api.BuildCancelled:
 		return build.Status, nil
 	default:
 		return api.BuildError, fmt.Errorf("Invalid build status: %s", build.Status)
 	}
 }
+
+// recordBuildOutput tags the image reported by the builder in the ImageRepository
+// of the build output, creating the Image in the process.
+func (bc *BuildController) recordBuildOutput(build *api.Build, result *BuildResult, resultErr error) {
+	if resultErr != nil {
+		build.Message = fmt.Sprintf("The output image could not be recorded: %v", resultErr)
+		return
+	}
+	if result == nil {
+		return
+	}
+	image := result.Image
+	if image == nil {
+		glog.Errorf("The builder of build ID %v did not report an image", build.ID)
+		build.Message = "The output image could not be recorded: the builder did not report an image"
+		return
+	}
+
+	repository, tag := outputImageRepository(bc.dockerRegistry, build.Input.ImageTag)
+	mapping := &imageapi.ImageRepositoryMapping{
+		DockerImageRepository: repository,
+		Tag:                   tag,
+		Image: imageapi.Image{
+			JSONBase:             kubeapi.JSONBase{ID: image.ID},
+			DockerImageReference: repository + ":" + tag,
+			Metadata:             *image,
+		},
+	}
+	if err := bc.osClient.CreateImageRepositoryMapping(mapping); err != nil {
+		glog.Errorf("Error recording image %s of build ID %v: %v", image.ID, build.ID, err)
+		build.Message = fmt.Sprintf("The output image could not be recorded: %v", err)
+	}
+}
+
+// readBuildResult reads the result reported by the builder of a terminated build
+// pod and records the revision of the build. It returns a nil result when no
+// result reader is configured.
+func (bc *BuildController) readBuildResult(build *api.Build, pod *kubeapi.Pod) (*BuildResult, error) {
+	if bc.resultReader == nil {
+		return nil, nil
+	}
+	result, err := bc.resultReader.ReadBuildResult(pod)
+	if err != nil {
+		glog.Errorf("Error reading the result of build ID %v: %v", build.ID, err)
+		return nil, err
+	}
+	if result.Revision != nil {
+		build.Revision = result.Revision
+	}
+	return result, nil
+}
+
+// cancel stops a build whose cancellation has been requested by deleting its pod.
+func (bc *BuildController) cancel(build *api.Build) (api.BuildStatus, error) {
+	if len(build.PodID) > 0 && build.Status != api.BuildNew {
+		if err := bc.kubeClient.DeletePod(build.PodID); err != nil && !isNotFound(err) {
+			return build.Status, fmt.Errorf("Error deleting pod %s of cancelled build ID %v: %#v", build.PodID, build.ID, err)
+		}
+	}
+	build.QueuePosition = 0
+	glog.Infof("Build %s was cancelled by %s", build.ID, build.CancelledBy)
+	return api.BuildCancelled, nil
+}
+
+// containerFailures describes every container of the pod that exited with a
+// non-zero exit code, in container name order.
+func containerFailures(pod *kubeapi.Pod) []string {
+	names := []string{}
+	for name := range pod.CurrentState.Info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := []string{}
+	for _, name := range names {
+		if exitCode := pod.CurrentState.Info[name].State.ExitCode; exitCode != 0 {
+			failures = append(failures, fmt.Sprintf("container %s exited with code %d", name, exitCode))
+		}
+	}
+	return failures
+}
+
+// isNotFound returns true if err indicates that the requested resource does
+// not exist, whether the error was returned by a client or by a registry.
+func isNotFound(err error) bool {
+	return hasStatusReason(err, kubeapi.StatusReasonNotFound) || errors.IsNotFound(err)
+}
+
+// isAlreadyExists returns true if err indicates that the resource being created
+// already exists, whether the error was returned by a client or by a registry.
+func isAlreadyExists(err error) bool {
+	return hasStatusReason(err, kubeapi.StatusReasonAlreadyExists) || errors.IsAlreadyExists(err)
+}
+
+// isRejected returns true if err indicates that the server refused a request
+// as invalid, rather than failing to process it.
+func isRejected(err error) bool {
+	if statusErr, ok := err.(*kubeclient.StatusErr); ok {
+		return statusErr.Status.Code >= 400 && statusErr.Status.Code < 500
+	}
+	return errors.IsInvalid(err)
+}
+
+// hasStatusReason returns true if err is a client error carrying the given reason.
+func hasStatusReason(err error, reason kubeapi.StatusReason) bool {
+	statusErr, ok := err.(*kubeclient.StatusErr)
+	return ok && statusErr.Status.Reason == reason
+}
@@ -2,13 +2,21 @@ package build
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/fsouza/go-dockerclient"
 	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	osclient "github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
 )
 
 type okOsClient struct{}
@@ -31,10 +39,89 @@ func (_ *errOsClient) UpdateBuild(build api.Build) (api.Build, error) {
 	return api.Build{}, errors.New("UpdateBuild error!")
 }
 
+type watchOsClient struct {
+	osclient.Fake
+	watcher *watch.FakeWatcher
+	builds  []api.Build
+	updated []api.Build
+}
+
+func (c *watchOsClient) WatchBuilds(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func (c *watchOsClient) ListBuilds(selector labels.Selector) (*api.BuildList, error) {
+	return &api.BuildList{Items: c.builds}, nil
+}
+
+func (c *watchOsClient) UpdateBuild(build *api.Build) (*api.Build, error) {
+	c.updated = append(c.updated, *build)
+	return build, nil
+}
+
+type mappingOsClient struct {
+	osclient.Fake
+	mappings []imageapi.ImageRepositoryMapping
+}
+
+func (c *mappingOsClient) CreateImageRepositoryMapping(mapping *imageapi.ImageRepositoryMapping) error {
+	c.mappings = append(c.mappings, *mapping)
+	return nil
+}
+
+type okResultReader struct{}
+
+func (_ *okResultReader) ReadBuildResult(pod *kubeapi.Pod) (*BuildResult, error) {
+	return &BuildResult{
+		Image:    &docker.Image{ID: "abc123", Author: "builder"},
+		Revision: &api.BuildRevision{Commit: "0123abcd", BuilderImageID: "def456"},
+	}, nil
+}
+
+type errResultReader struct{}
+
+func (_ *errResultReader) ReadBuildResult(pod *kubeapi.Pod) (*BuildResult, error) {
+	return nil, errors.New("ReadBuildResult error!")
+}
+
 type okStrategy struct{}
 
-func (_ *okStrategy) CreateBuildPod(build *api.Build, dockerRegistry string) *kubeapi.Pod {
-	return &kubeapi.Pod{}
+func (_ *okStrategy) CreateBuildPod(build *api.Build, dockerRegistry string, credentials *strategy.CredentialsSource) (*kubeapi.Pod, error) {
+	return &kubeapi.Pod{}, nil
+}
+
+type errStrategy struct{}
+
+func (_ *errStrategy) CreateBuildPod(build *api.Build, dockerRegistry string, credentials *strategy.CredentialsSource) (*kubeapi.Pod, error) {
+	return nil, errors.New("CreateBuildPod error!")
+}
+
+type credentialsStrategy struct {
+	credentials *strategy.CredentialsSource
+}
+
+func (s *credentialsStrategy) CreateBuildPod(build *api.Build, dockerRegistry string, credentials *strategy.CredentialsSource) (*kubeapi.Pod, error) {
+	s.credentials = credentials
+	return &kubeapi.Pod{}, nil
+}
+
+type credentialsConfigGetter struct {
+	config *api.BuildConfig
+}
+
+func (g *credentialsConfigGetter) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	if g.config == nil || g.config.ID != id {
+		return nil, kubeerrors.NewNotFound("buildConfig", id)
+	}
+	return g.config, nil
+}
+
+// credentialsTokens records the credentials tokens issued for builds
+type credentialsTokens map[string]string
+
+func (t credentialsTokens) SetBuildCredentialsToken(id, token string, ttl uint64) error {
+	t[id] = token
+	return nil
 }
 
 type errKubeClient struct {
@@ -49,6 +136,10 @@ func (_ *errKubeClient) GetPod(name string) (kubeapi.Pod, error) {
 	return kubeapi.Pod{}, errors.New("GedPod error!")
 }
 
+func (_ *errKubeClient) DeletePod(name string) error {
+	return errors.New("DeletePod error!")
+}
+
 type okKubeClient struct {
 	kubeclient.Fake
 }
@@ -59,6 +150,61 @@ func (_ *okKubeClient) GetPod(name string) (kubeapi.Pod, error) {
 	}, nil
 }
 
+type missingPodKubeClient struct {
+	kubeclient.Fake
+}
+
+func (_ *missingPodKubeClient) GetPod(name string) (kubeapi.Pod, error) {
+	return kubeapi.Pod{}, &kubeclient.StatusErr{Status: kubeapi.Status{Reason: kubeapi.StatusReasonNotFound}}
+}
+
+type existingPodKubeClient struct {
+	kubeclient.Fake
+}
+
+func (_ *existingPodKubeClient) CreatePod(pod kubeapi.Pod) (kubeapi.Pod, error) {
+	return kubeapi.Pod{}, kubeerrors.NewAlreadyExists("pod", pod.ID)
+}
+
+type rejectingKubeClient struct {
+	kubeclient.Fake
+}
+
+func (_ *rejectingKubeClient) CreatePod(pod kubeapi.Pod) (kubeapi.Pod, error) {
+	return kubeapi.Pod{}, &kubeclient.StatusErr{Status: kubeapi.Status{
+		Status: kubeapi.StatusFailure,
+		Code:   422,
+		Reason: kubeapi.StatusReasonInvalid,
+	}}
+}
+
+type failedContainerKubeClient struct {
+	kubeclient.Fake
+}
+
+func (_ *failedContainerKubeClient) GetPod(name string) (kubeapi.Pod, error) {
+	return kubeapi.Pod{
+		CurrentState: kubeapi.PodState{
+			Status: kubeapi.PodTerminated,
+			Info: kubeapi.PodInfo{
+				"sti-build": docker.Container{State: docker.State{ExitCode: 2}},
+				"helper":    docker.Container{State: docker.State{ExitCode: 0}},
+				"assemble":  docker.Container{State: docker.State{ExitCode: 1}},
+			},
+		},
+	}, nil
+}
+
+type runningKubeClient struct {
+	kubeclient.Fake
+}
+
+func (_ *runningKubeClient) GetPod(name string) (kubeapi.Pod, error) {
+	return kubeapi.Pod{
+		CurrentState: kubeapi.PodState{Status: kubeapi.PodRunning},
+	}, nil
+}
+
 func TestSynchronizeBuildNew(t *testing.T) {
 	ctrl, build := setup()
 	build.Status = api.BuildNew
@@ -82,6 +228,9 @@ func TestSynchronizeBuildPendingUnknownStrategy(t *testing.T) {
 	if status != api.BuildError {
 		t.Errorf("Expected BuildError, got %s!", status)
 	}
+	if build.Reason != api.BuildReasonUnknownStrategy || len(build.Message) == 0 {
+		t.Errorf("Expected reason %s with a message, got %s: %q", api.BuildReasonUnknownStrategy, build.Reason, build.Message)
+	}
 }
 
 func TestSynchronizeBuildPendingFailedCreatePod(t *testing.T) {
@@ -92,9 +241,124 @@ func TestSynchronizeBuildPendingFailedCreatePod(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error, but none happened!")
 	}
+	if status != api.BuildError {
+		t.Errorf("Expected BuildError, got %s!", status)
+	}
+	if build.Reason != api.BuildReasonPodCreationFailed || !strings.Contains(build.Message, "CreatePod error!") {
+		t.Errorf("Expected reason %s with the pod error, got %s: %q", api.BuildReasonPodCreationFailed, build.Reason, build.Message)
+	}
+}
+
+func TestSynchronizeBuildPendingRejectedCreatePod(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &rejectingKubeClient{}
+	build.Status = api.BuildPending
+	status, err := ctrl.synchronize(build)
+	if err == nil {
+		t.Error("Expected error, but none happened!")
+	}
 	if status != api.BuildFailed {
 		t.Errorf("Expected BuildFailed, got %s!", status)
 	}
+	if build.Reason != api.BuildReasonPodCreationRejected {
+		t.Errorf("Expected reason %s, got %s: %q", api.BuildReasonPodCreationRejected, build.Reason, build.Message)
+	}
+}
+
+func TestSynchronizeBuildPendingFailedCreateBuildPod(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.buildStrategies["okStrategy"] = &errStrategy{}
+	build.Status = api.BuildPending
+	status, err := ctrl.synchronize(build)
+	if err == nil {
+		t.Error("Expected error, but none happened!")
+	}
+	if status != api.BuildError {
+		t.Errorf("Expected BuildError, got %s!", status)
+	}
+	if !strings.Contains(build.Message, "CreateBuildPod error!") {
+		t.Errorf("Expected the strategy error in the message, got %q", build.Message)
+	}
+}
+
+func TestSynchronizeBuildPendingWithCredentials(t *testing.T) {
+	ctrl, build := setup()
+	buildStrategy := &credentialsStrategy{}
+	ctrl.buildStrategies["okStrategy"] = buildStrategy
+	ctrl.configs = &credentialsConfigGetter{config: &api.BuildConfig{
+		JSONBase:    kubeapi.JSONBase{ID: "config"},
+		Credentials: &api.BuildCredentials{DockerConfig: "{}"},
+	}}
+	tokens := credentialsTokens{}
+	ctrl.credentials = NewCredentialsIssuer(tokens, "http://master:8080/osapi/v1beta1/")
+	build.Status = api.BuildPending
+	build.Config = &api.BuildConfigReference{ID: "config", Version: 1}
+	if status, err := ctrl.synchronize(build); err != nil || status != api.BuildRunning {
+		t.Fatalf("Expected BuildRunning, got %s: %v", status, err)
+	}
+	credentials := buildStrategy.credentials
+	if credentials == nil {
+		t.Fatalf("Expected the strategy to receive the location of the credentials")
+	}
+	if e, a := "http://master:8080/osapi/v1beta1/builds/dataBuild/credentials", credentials.URL; e != a {
+		t.Errorf("Expected credentials URL %s, got %s", e, a)
+	}
+	if len(credentials.Token) != 64 || tokens["dataBuild"] != credentials.Token {
+		t.Errorf("Expected the token %q to be stored, got %v", credentials.Token, tokens)
+	}
+
+	build.Config.ID = "deletedConfig"
+	if status, err := ctrl.synchronize(build); err != nil || status != api.BuildRunning {
+		t.Fatalf("Expected BuildRunning, got %s: %v", status, err)
+	}
+	if buildStrategy.credentials != nil {
+		t.Errorf("Expected no credentials for a missing config, got %#v", buildStrategy.credentials)
+	}
+}
+
+func TestSynchronizeBuildPendingWithCredentialsNoURL(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.configs = &credentialsConfigGetter{config: &api.BuildConfig{
+		JSONBase:    kubeapi.JSONBase{ID: "config"},
+		Credentials: &api.BuildCredentials{DockerConfig: "{}"},
+	}}
+	tokens := credentialsTokens{}
+	ctrl.credentials = NewCredentialsIssuer(tokens, "")
+	build.Status = api.BuildPending
+	build.Config = &api.BuildConfigReference{ID: "config", Version: 1}
+	if status, err := ctrl.synchronize(build); err != ErrNoBuildPodAPIURL || status != api.BuildError {
+		t.Fatalf("Expected BuildError, got %s: %v", status, err)
+	}
+	if !strings.Contains(build.Message, "OPENSHIFT_MASTER_URL") {
+		t.Errorf("Expected the message to name OPENSHIFT_MASTER_URL, got %q", build.Message)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("Expected no token to be stored, got %v", tokens)
+	}
+
+	build.Status = api.BuildPending
+	build.Config.ID = "deletedConfig"
+	if status, err := ctrl.synchronize(build); err != nil || status != api.BuildRunning {
+		t.Fatalf("Expected a build without credentials to run, got %s: %v", status, err)
+	}
+}
+
+func TestSynchronizeBuildPendingPodAlreadyExists(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &existingPodKubeClient{}
+	build.Status = api.BuildPending
+	build.Reason = api.BuildReasonPodDeleted
+	build.Message = "Attempt 2 of 3 will start in 1s"
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s!", status)
+	}
+	if len(build.Reason) != 0 || len(build.Message) != 0 {
+		t.Errorf("Expected no reason nor message, got %s: %q", build.Reason, build.Message)
+	}
 }
 
 func TestSynchronizeBuildPending(t *testing.T) {
@@ -110,10 +374,50 @@ func TestSynchronizeBuildPending(t *testing.T) {
 }
 
 func TestSynchronizeBuildRunningTimedOut(t *testing.T) {
+	ctrl, build := setup()
+	build.Status = api.BuildRunning
+	build.StartTimestamp.Time = time.Date(0, 0, 0, 0, 0, 0, 0, time.UTC)
+	status, err := ctrl.synchronize(build)
+	if err == nil {
+		t.Error("Expected error, but none happened!")
+	}
+	if status != api.BuildFailed {
+		t.Errorf("Expected BuildFailed, got %s!", status)
+	}
+	if build.Reason != api.BuildReasonTimedOut {
+		t.Errorf("Expected reason %s, got %s", api.BuildReasonTimedOut, build.Reason)
+	}
+}
+
+func TestSynchronizeBuildRunningNotStartedNoTimeout(t *testing.T) {
 	ctrl, build := setup()
 	build.Status = api.BuildRunning
 	build.CreationTimestamp.Time = time.Date(0, 0, 0, 0, 0, 0, 0, time.UTC)
 	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s!", status)
+	}
+}
+
+func TestSynchronizeBuildRunningTimeoutOverride(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.timeout = 10
+	build.Status = api.BuildRunning
+	build.StartTimestamp.Time = time.Now().Add(-100 * time.Second)
+	build.Input.TimeoutSeconds = 3600
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s!", status)
+	}
+
+	build.Input.TimeoutSeconds = 1
+	status, err = ctrl.synchronize(build)
 	if err == nil {
 		t.Error("Expected error, but none happened!")
 	}
@@ -122,6 +426,174 @@ func TestSynchronizeBuildRunningTimedOut(t *testing.T) {
 	}
 }
 
+func TestSynchronizeBuildRunningSetsStartTimestamp(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &runningKubeClient{}
+	build.Status = api.BuildRunning
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s!", status)
+	}
+	if build.StartTimestamp.IsZero() {
+		t.Errorf("Expected the start timestamp to be set")
+	}
+}
+
+func TestSyncBuildRecordsCompletion(t *testing.T) {
+	ctrl, build := setup()
+	client := &watchOsClient{}
+	ctrl.osClient = client
+	ctrl.kubeClient = &okKubeClient{}
+	build.Status = api.BuildRunning
+	build.StartTimestamp.Time = time.Now().Add(-time.Minute)
+
+	ctrl.syncBuild(build)
+
+	if len(client.updated) != 1 {
+		t.Fatalf("Expected 1 build update, got %d", len(client.updated))
+	}
+	updated := client.updated[0]
+	if updated.Status != api.BuildComplete {
+		t.Errorf("Expected BuildComplete, got %s!", updated.Status)
+	}
+	if updated.CompletionTimestamp.IsZero() {
+		t.Errorf("Expected the completion timestamp to be set")
+	}
+	if updated.Duration < time.Minute {
+		t.Errorf("Expected a duration of at least a minute, got %v", updated.Duration)
+	}
+}
+
+func TestSyncBuildRecordsStatusHistory(t *testing.T) {
+	ctrl, build := setup()
+	client := &watchOsClient{}
+	ctrl.osClient = client
+	ctrl.kubeClient = &failedContainerKubeClient{}
+
+	for i := 0; i < 4; i++ {
+		ctrl.syncBuild(build)
+	}
+
+	if len(client.updated) != 3 {
+		t.Fatalf("Expected 3 build updates, got %d", len(client.updated))
+	}
+	history := client.updated[2].StatusHistory
+	expected := []api.BuildStatus{api.BuildNew, api.BuildPending, api.BuildRunning, api.BuildFailed}
+	if len(history) != len(expected)-1 {
+		t.Fatalf("Expected %d status transitions, got %#v", len(expected)-1, history)
+	}
+	for i, transition := range history {
+		if transition.From != expected[i] || transition.To != expected[i+1] {
+			t.Errorf("Expected a transition from %s to %s, got %#v", expected[i], expected[i+1], transition)
+		}
+		if transition.Timestamp.IsZero() || i > 0 && transition.Timestamp.Before(history[i-1].Timestamp.Time) {
+			t.Errorf("Expected ordered transition timestamps, got %#v", history)
+		}
+	}
+	if len(history[0].Message) != 0 {
+		t.Errorf("Expected no message for the transition to pending, got %s", history[0].Message)
+	}
+	if last := history[len(history)-1]; len(last.Message) == 0 || last.Message != build.Message {
+		t.Errorf("Expected the failure message for the transition to failed, got %s", last.Message)
+	}
+}
+
+func TestSyncBuildRecordsMetrics(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.osClient = &watchOsClient{}
+	ctrl.kubeClient = &okKubeClient{}
+	build.Status = api.BuildRunning
+	build.StartTimestamp.Time = time.Now().Add(-time.Minute)
+	completed := buildsCompleted.Value(string(api.BuildComplete))
+	durations := buildDuration.Count(string(api.BuildComplete))
+
+	ctrl.syncBuild(build)
+
+	if value := buildsCompleted.Value(string(api.BuildComplete)); value != completed+1 {
+		t.Errorf("Expected the completed build to be counted, got %v", value-completed)
+	}
+	if count := buildDuration.Count(string(api.BuildComplete)); count != durations+1 {
+		t.Errorf("Expected the duration of the build to be observed, got %d", count-durations)
+	}
+}
+
+func TestSyncBuildConflictRecordsNoMetrics(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &okKubeClient{}
+	build.Status = api.BuildRunning
+	ctrl.osClient = &conflictOsClient{conflicts: buildutil.MaxConflictRetries + 1, current: *build}
+	completed := buildsCompleted.Value(string(api.BuildComplete))
+
+	ctrl.syncBuild(build)
+
+	if value := buildsCompleted.Value(string(api.BuildComplete)); value != completed {
+		t.Errorf("Expected a build which was not stored not to be counted, got %v", value-completed)
+	}
+}
+
+// conflictOsClient rejects the first updates with a conflict, as if the build
+// had been modified concurrently into current.
+type conflictOsClient struct {
+	watchOsClient
+	conflicts int
+	current   api.Build
+}
+
+func (c *conflictOsClient) GetBuild(id string) (*api.Build, error) {
+	current := c.current
+	return &current, nil
+}
+
+func (c *conflictOsClient) UpdateBuild(build *api.Build) (*api.Build, error) {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return nil, kubeerrors.NewConflict("build", build.ID, errors.New("modified"))
+	}
+	return c.watchOsClient.UpdateBuild(build)
+}
+
+func TestSyncBuildConflict(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &okKubeClient{}
+	build.Status = api.BuildRunning
+	current := *build
+	current.Cancelled = true
+	client := &conflictOsClient{conflicts: 1, current: current}
+	ctrl.osClient = client
+
+	ctrl.syncBuild(build)
+
+	if len(client.updated) != 1 {
+		t.Fatalf("Expected 1 build update, got %d", len(client.updated))
+	}
+	if status := client.updated[0].Status; status != api.BuildCancelled {
+		t.Errorf("Expected the current build to be synchronized to BuildCancelled, got %s!", status)
+	}
+	if build.Status != api.BuildCancelled {
+		t.Errorf("Expected the build to be replaced by its current version, got %s!", build.Status)
+	}
+}
+
+func TestSyncBuildConflictGivesUp(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &okKubeClient{}
+	build.Status = api.BuildRunning
+	client := &conflictOsClient{conflicts: buildutil.MaxConflictRetries + 1, current: *build}
+	ctrl.osClient = client
+
+	ctrl.syncBuild(build)
+
+	if len(client.updated) != 0 {
+		t.Errorf("Expected no build update, got %d", len(client.updated))
+	}
+	if client.conflicts != 0 {
+		t.Errorf("Expected %d update attempts, got %d", buildutil.MaxConflictRetries+1, buildutil.MaxConflictRetries+1-client.conflicts)
+	}
+}
+
 func TestSynchronizeBuildRunningFailedGetPod(t *testing.T) {
 	ctrl, build := setup()
 	ctrl.kubeClient = &errKubeClient{}
@@ -136,6 +608,42 @@ func TestSynchronizeBuildRunningFailedGetPod(t *testing.T) {
 	}
 }
 
+func TestSynchronizeBuildRunningPodDeleted(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &missingPodKubeClient{}
+	build.Status = api.BuildRunning
+	status, err := ctrl.synchronize(build)
+	if err == nil {
+		t.Error("Expected error, but none happened!")
+	}
+	if status != api.BuildError {
+		t.Errorf("Expected BuildError, got %s!", status)
+	}
+	if build.Reason != api.BuildReasonPodDeleted {
+		t.Errorf("Expected reason %s, got %s", api.BuildReasonPodDeleted, build.Reason)
+	}
+}
+
+func TestSynchronizeBuildRunningContainerFailed(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &failedContainerKubeClient{}
+	build.Status = api.BuildRunning
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildFailed {
+		t.Errorf("Expected BuildFailed, got %s!", status)
+	}
+	if build.Reason != api.BuildReasonContainerFailed {
+		t.Errorf("Expected reason %s, got %s", api.BuildReasonContainerFailed, build.Reason)
+	}
+	expected := "container assemble exited with code 1; container sti-build exited with code 2"
+	if build.Message != expected {
+		t.Errorf("Expected message %q, got %q", expected, build.Message)
+	}
+}
+
 func TestSynchronizeBuildRunningPodRunning(t *testing.T) {
 	ctrl, build := setup()
 	build.Status = api.BuildRunning
@@ -163,6 +671,78 @@ func TestSynchronizeBuildRunningPodTerminated(t *testing.T) {
 	}
 }
 
+func TestSynchronizeBuildRunningRecordsOutput(t *testing.T) {
+	ctrl, build := setup()
+	client := &mappingOsClient{}
+	ctrl.osClient = client
+	ctrl.kubeClient = &okKubeClient{}
+	ctrl.resultReader = &okResultReader{}
+	ctrl.dockerRegistry = "registry:5000"
+	build.Status = api.BuildRunning
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildComplete {
+		t.Errorf("Expected BuildComplete, got %s!", status)
+	}
+	if len(client.mappings) != 1 {
+		t.Fatalf("Expected 1 image repository mapping, got %#v", client.mappings)
+	}
+	mapping := client.mappings[0]
+	if e, a := "registry:5000/repository/dataBuild", mapping.DockerImageRepository; e != a {
+		t.Errorf("Expected repository %s, got %s", e, a)
+	}
+	if e, a := "latest", mapping.Tag; e != a {
+		t.Errorf("Expected tag %s, got %s", e, a)
+	}
+	if mapping.Image.ID != "abc123" || mapping.Image.Metadata.Author != "builder" ||
+		mapping.Image.DockerImageReference != "registry:5000/repository/dataBuild:latest" {
+		t.Errorf("Unexpected image: %#v", mapping.Image)
+	}
+	if build.Revision == nil || build.Revision.Commit != "0123abcd" {
+		t.Errorf("Expected the revision of the build to be recorded, got %#v", build.Revision)
+	}
+}
+
+func TestSynchronizeBuildFailedRecordsRevision(t *testing.T) {
+	ctrl, build := setup()
+	client := &mappingOsClient{}
+	ctrl.osClient = client
+	ctrl.kubeClient = &failedContainerKubeClient{}
+	ctrl.resultReader = &okResultReader{}
+	build.Status = api.BuildRunning
+	status, _ := ctrl.synchronize(build)
+	if status != api.BuildFailed {
+		t.Errorf("Expected BuildFailed, got %s!", status)
+	}
+	if len(client.mappings) != 0 {
+		t.Errorf("Expected no image repository mapping, got %#v", client.mappings)
+	}
+	if build.Revision == nil || build.Revision.BuilderImageID != "def456" {
+		t.Errorf("Expected the revision of the failed build to be recorded, got %#v", build.Revision)
+	}
+}
+
+func TestSynchronizeBuildRunningOutputNotReported(t *testing.T) {
+	ctrl, build := setup()
+	client := &mappingOsClient{}
+	ctrl.osClient = client
+	ctrl.kubeClient = &okKubeClient{}
+	ctrl.resultReader = &errResultReader{}
+	build.Status = api.BuildRunning
+	status, _ := ctrl.synchronize(build)
+	if status != api.BuildComplete {
+		t.Errorf("Expected BuildComplete, got %s!", status)
+	}
+	if len(client.mappings) != 0 {
+		t.Errorf("Expected no image repository mapping, got %#v", client.mappings)
+	}
+	if !strings.Contains(build.Message, "ReadBuildResult error!") {
+		t.Errorf("Expected the error in the build message, got %q", build.Message)
+	}
+}
+
 func TestSynchronizeBuildComplete(t *testing.T) {
 	ctrl, build := setup()
 	build.Status = api.BuildComplete
@@ -199,6 +779,54 @@ func TestSynchronizeBuildError(t *testing.T) {
 	}
 }
 
+func TestSynchronizeBuildCancelled(t *testing.T) {
+	for _, status := range []api.BuildStatus{api.BuildNew, api.BuildPending, api.BuildRunning} {
+		ctrl, build := setup()
+		kubeClient := &kubeclient.Fake{}
+		ctrl.kubeClient = kubeClient
+		build.Status = status
+		build.Cancelled = true
+		nextStatus, err := ctrl.synchronize(build)
+		if err != nil {
+			t.Errorf("%s: Unexpected error, got %s!", status, err.Error())
+		}
+		if nextStatus != api.BuildCancelled {
+			t.Errorf("%s: Expected BuildCancelled, got %s!", status, nextStatus)
+		}
+		deleted := len(kubeClient.Actions) == 1 && kubeClient.Actions[0].Action == "delete-pod"
+		if e, a := status != api.BuildNew, deleted; e != a {
+			t.Errorf("%s: Expected pod deletion %t, got actions %#v", status, e, kubeClient.Actions)
+		}
+	}
+}
+
+func TestSynchronizeBuildCancelledFailedDeletePod(t *testing.T) {
+	ctrl, build := setup()
+	ctrl.kubeClient = &errKubeClient{}
+	build.Status = api.BuildRunning
+	build.Cancelled = true
+	status, err := ctrl.synchronize(build)
+	if err == nil {
+		t.Error("Expected error, but none happened!")
+	}
+	if status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s!", status)
+	}
+}
+
+func TestSynchronizeBuildCancelledIsTerminal(t *testing.T) {
+	ctrl, build := setup()
+	build.Status = api.BuildCancelled
+	build.Cancelled = true
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error, got %s!", err.Error())
+	}
+	if status != api.BuildCancelled {
+		t.Errorf("Expected BuildCancelled, got %s!", status)
+	}
+}
+
 func TestSynchronizeBuildUnknownStatus(t *testing.T) {
 	ctrl, build := setup()
 	build.Status = "unknownBuildStatus"
@@ -211,6 +839,80 @@ func TestSynchronizeBuildUnknownStatus(t *testing.T) {
 	}
 }
 
+func TestWatchBuildsSyncsWatchedBuild(t *testing.T) {
+	ctrl, build := setup()
+	client := &watchOsClient{watcher: watch.NewFake()}
+	ctrl.osClient = client
+	build.ResourceVersion = 5
+
+	go func() {
+		client.watcher.Add(build)
+		client.watcher.Stop()
+	}()
+	resourceVersion := uint64(0)
+	ctrl.watchBuilds(&resourceVersion, nil)
+
+	if resourceVersion != 6 {
+		t.Errorf("Expected resource version 6, got %d", resourceVersion)
+	}
+	if len(client.updated) != 1 {
+		t.Fatalf("Expected 1 build update, got %d", len(client.updated))
+	}
+	if client.updated[0].Status != api.BuildPending {
+		t.Errorf("Expected BuildPending, got %s!", client.updated[0].Status)
+	}
+}
+
+func TestWatchBuildsIgnoresDeletedBuild(t *testing.T) {
+	ctrl, build := setup()
+	client := &watchOsClient{watcher: watch.NewFake()}
+	ctrl.osClient = client
+
+	go func() {
+		client.watcher.Delete(build)
+		client.watcher.Stop()
+	}()
+	resourceVersion := uint64(0)
+	ctrl.watchBuilds(&resourceVersion, nil)
+
+	if len(client.updated) != 0 {
+		t.Errorf("Unexpected build updates: %#v", client.updated)
+	}
+}
+
+func TestWatchBuildsStops(t *testing.T) {
+	ctrl, _ := setup()
+	client := &watchOsClient{watcher: watch.NewFake()}
+	ctrl.osClient = client
+
+	stop := make(chan struct{})
+	close(stop)
+	resourceVersion := uint64(0)
+	ctrl.watchBuilds(&resourceVersion, stop)
+
+	if !client.watcher.Stopped {
+		t.Errorf("Expected the watch to be stopped")
+	}
+}
+
+func TestSynchronizeAllSkipsTerminalBuilds(t *testing.T) {
+	ctrl, build := setup()
+	completed := *build
+	completed.ID = "completedBuild"
+	completed.Status = api.BuildComplete
+	client := &watchOsClient{builds: []api.Build{*build, completed}}
+	ctrl.osClient = client
+
+	ctrl.synchronizeAll()
+
+	if len(client.updated) != 1 {
+		t.Fatalf("Expected 1 build update, got %d", len(client.updated))
+	}
+	if client.updated[0].ID != build.ID {
+		t.Errorf("Expected build %s to be updated, got %s", build.ID, client.updated[0].ID)
+	}
+}
+
 func setup() (buildController *BuildController, build *api.Build) {
 	buildController = &BuildController{
 		buildStrategies: map[api.BuildType]BuildJobStrategy{
@@ -224,9 +926,9 @@ func setup() (buildController *BuildController, build *api.Build) {
 			ID: "dataBuild",
 		},
 		Input: api.BuildInput{
-			Type:      "okStrategy",
-			SourceURI: "http://my.build.com/the/build/Dockerfile",
-			ImageTag:  "repository/dataBuild",
+			Type:     "okStrategy",
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			ImageTag: "repository/dataBuild",
 		},
 		Status: api.BuildNew,
 		PodID:  "-the-pod-id",
@@ -0,0 +1,71 @@
+package build
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
+)
+
+// credentialsTokenTTL is the number of seconds within which the builder of a
+// build must fetch the credentials of the build. Builders fetch them as soon as
+// they start.
+const credentialsTokenTTL = 3600
+
+// ErrNoBuildPodAPIURL is returned when the credentials of a build are issued
+// while no URL of the OpenShift API reachable from build pods is configured.
+var ErrNoBuildPodAPIURL = errors.New("no URL of the OpenShift API reachable from build pods is configured, set OPENSHIFT_MASTER_URL")
+
+// CredentialsTokenStore stores the tokens with which builder containers fetch
+// the credentials of their build.
+type CredentialsTokenStore interface {
+	SetBuildCredentialsToken(id, token string, ttl uint64) error
+}
+
+// CredentialsIssuer gives build pods access to the credentials of their build.
+// The credentials never appear in the pod definition, which anyone allowed to
+// list pods can read. Instead the builder container fetches them from the
+// builds/<id>/credentials operation of the master with a token valid once.
+type CredentialsIssuer struct {
+	tokens  CredentialsTokenStore
+	baseURL string
+}
+
+// NewCredentialsIssuer creates a new CredentialsIssuer for the OpenShift API
+// at baseURL, which must be reachable from build pods. An empty baseURL fails
+// only the builds which have credentials.
+func NewCredentialsIssuer(tokens CredentialsTokenStore, baseURL string) *CredentialsIssuer {
+	return &CredentialsIssuer{tokens, strings.TrimRight(baseURL, "/")}
+}
+
+// Issue stores a new credentials token for the build and returns where the
+// builder fetches the credentials with it. A pod created again for the build
+// receives a new token.
+func (i *CredentialsIssuer) Issue(build *api.Build) (*strategy.CredentialsSource, error) {
+	if len(i.baseURL) == 0 {
+		return nil, ErrNoBuildPodAPIURL
+	}
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return nil, fmt.Errorf("Error generating the credentials token of build ID %v: %v", build.ID, err)
+	}
+	token := hex.EncodeToString(data)
+	if err := i.tokens.SetBuildCredentialsToken(build.ID, token, credentialsTokenTTL); err != nil {
+		return nil, fmt.Errorf("Error storing the credentials token of build ID %v: %v", build.ID, err)
+	}
+	return &strategy.CredentialsSource{URL: i.location(build), Token: token}, nil
+}
+
+// Redacted returns where the builder fetches the credentials of the build,
+// with RedactedValue in place of a token.
+func (i *CredentialsIssuer) Redacted(build *api.Build) *strategy.CredentialsSource {
+	return &strategy.CredentialsSource{URL: i.location(build), Token: RedactedValue}
+}
+
+func (i *CredentialsIssuer) location(build *api.Build) string {
+	return i.baseURL + "/builds/" + build.ID + "/credentials"
+}
@@ -5,7 +5,10 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
 	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
 )
 
 // EtcdRegistry implements build.Registry and buildconfig.Registry backed by etcd.
@@ -60,18 +63,37 @@ func (r *EtcdRegistry) GetBuild(id string) (*api.Build, error) {
 	return &build, nil
 }
 
-// CreateBuild creates a new Build.
+// WatchBuilds begins watching for new, changed, or deleted Builds.
+func (r *EtcdRegistry) WatchBuilds(resourceVersion uint64, filter func(build *api.Build) bool) (watch.Interface, error) {
+	return r.WatchList("/registry/builds", resourceVersion, func(obj interface{}) bool {
+		build, ok := obj.(*api.Build)
+		if !ok {
+			glog.Errorf("Unexpected object during build watch: %#v", obj)
+			return false
+		}
+		return filter(build)
+	})
+}
+
+// CreateBuild creates a new Build, labeled with its status.
 func (r *EtcdRegistry) CreateBuild(build *api.Build) error {
+	buildutil.SetStatusLabels(build)
 	err := r.CreateObj(makeBuildKey(build.ID), build)
 	if tools.IsEtcdNodeExist(err) {
 		return errors.NewAlreadyExists("build", build.ID)
 	}
+	if err == nil {
+		buildsCreated.Inc(string(build.Input.Type))
+	}
 	return err
 }
 
-// UpdateBuild replaces an existing Build.
+// UpdateBuild replaces an existing Build if it has not been modified since the
+// ResourceVersion of build. A conflict error is returned otherwise. The labels
+// reflecting the status of the build are updated.
 func (r *EtcdRegistry) UpdateBuild(build *api.Build) error {
-	return r.SetObj(makeBuildKey(build.ID), build)
+	buildutil.SetStatusLabels(build)
+	return r.compareAndSwap("build", build.ID, makeBuildKey(build.ID), build)
 }
 
 // DeleteBuild deletes a Build specified by its ID.
@@ -118,6 +140,18 @@ func (r *EtcdRegistry) GetBuildConfig(id string) (*api.BuildConfig, error) {
 	return &config, nil
 }
 
+// WatchBuildConfigs begins watching for new, changed, or deleted BuildConfigs.
+func (r *EtcdRegistry) WatchBuildConfigs(resourceVersion uint64, filter func(config *api.BuildConfig) bool) (watch.Interface, error) {
+	return r.WatchList("/registry/build-configs", resourceVersion, func(obj interface{}) bool {
+		config, ok := obj.(*api.BuildConfig)
+		if !ok {
+			glog.Errorf("Unexpected object during build config watch: %#v", obj)
+			return false
+		}
+		return filter(config)
+	})
+}
+
 // CreateBuildConfig creates a new BuildConfig.
 func (r *EtcdRegistry) CreateBuildConfig(config *api.BuildConfig) error {
 	err := r.CreateObj(makeBuildConfigKey(config.ID), config)
@@ -127,9 +161,10 @@ func (r *EtcdRegistry) CreateBuildConfig(config *api.BuildConfig) error {
 	return err
 }
 
-// UpdateBuildConfig replaces an existing BuildConfig.
+// UpdateBuildConfig replaces an existing BuildConfig if it has not been modified
+// since the ResourceVersion of config. A conflict error is returned otherwise.
 func (r *EtcdRegistry) UpdateBuildConfig(config *api.BuildConfig) error {
-	return r.SetObj(makeBuildConfigKey(config.ID), config)
+	return r.compareAndSwap("buildConfig", config.ID, makeBuildConfigKey(config.ID), config)
 }
 
 // DeleteBuildConfig deletes a BuildConfig specified by its ID.
@@ -141,3 +176,48 @@ func (r *EtcdRegistry) DeleteBuildConfig(id string) error {
 	}
 	return err
 }
+
+// compareAndSwap stores obj under key if the index of the stored object is the
+// ResourceVersion of obj. An object without ResourceVersion can only be stored
+// under a key which does not exist yet.
+func (r *EtcdRegistry) compareAndSwap(kind, id, key string, obj interface{}) error {
+	err := r.SetObj(key, obj)
+	switch {
+	case tools.IsEtcdTestFailed(err), tools.IsEtcdNodeExist(err):
+		return errors.NewConflict(kind, id, err)
+	case tools.IsEtcdNotFound(err):
+		return errors.NewNotFound(kind, id)
+	}
+	return err
+}
+
+func makeBuildCredentialsTokenKey(id string) string {
+	return "/registry/build-credentials-tokens/" + id
+}
+
+// redeemedCredentialsToken replaces a credentials token once it has been used.
+const redeemedCredentialsToken = "redeemed"
+
+// SetBuildCredentialsToken stores the token with which the builder of a build
+// may fetch the credentials of the build once, within ttl seconds.
+func (r *EtcdRegistry) SetBuildCredentialsToken(id, token string, ttl uint64) error {
+	_, err := r.Client.Set(makeBuildCredentialsTokenKey(id), token, ttl)
+	return err
+}
+
+// RedeemBuildCredentialsToken returns true if token is the credentials token
+// of a build which has not been used yet, and marks it as used. The token is
+// swapped atomically, so that it can be redeemed only once across masters.
+func (r *EtcdRegistry) RedeemBuildCredentialsToken(id, token string) (bool, error) {
+	if len(token) == 0 || token == redeemedCredentialsToken {
+		return false, nil
+	}
+	_, err := r.Client.CompareAndSwap(makeBuildCredentialsTokenKey(id), redeemedCredentialsToken, credentialsTokenTTL, token, 0)
+	switch {
+	case err == nil:
+		return true, nil
+	case tools.IsEtcdTestFailed(err), tools.IsEtcdNotFound(err):
+		return false, nil
+	}
+	return false, err
+}
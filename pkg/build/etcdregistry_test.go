@@ -1,15 +1,16 @@
 package build
 
 import (
-	//	"reflect"
+	"reflect"
 	"testing"
 
 	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
-	//	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
 	"github.com/openshift/origin/pkg/build/api"
 	_ "github.com/openshift/origin/pkg/build/api/v1beta1"
@@ -34,6 +35,23 @@ func TestEtcdGetBuild(t *testing.T) {
 	}
 }
 
+func TestEtcdGetBuildWithLegacySource(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.Set("/registry/builds/foo", `{"kind":"Build","apiVersion":"v1beta1","id":"foo","input":{"type":"docker","sourceURI":"git://github.com/openshift/ruby-hello-world.git","sourceRef":"beta"}}`, 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	build, err := registry.GetBuild("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := api.BuildSource{
+		Type: api.GitBuildSourceType,
+		Git:  &api.GitBuildSource{URI: "git://github.com/openshift/ruby-hello-world.git", Ref: "beta"},
+	}
+	if !reflect.DeepEqual(build.Input.Source, expected) {
+		t.Errorf("Expected the legacy source to be converted to %#v, got %#v", expected, build.Input.Source)
+	}
+}
+
 func TestEtcdGetBuildNotFound(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.Data["/registry/builds/foo"] = tools.EtcdResponseWithError{
@@ -59,14 +77,15 @@ func TestEtcdCreateBuild(t *testing.T) {
 		E: tools.EtcdErrorNotFound,
 	}
 	registry := NewTestEtcdRegistry(fakeClient)
+	created := buildsCreated.Value(string(api.DockerBuildType))
 	err := registry.CreateBuild(&api.Build{
 		JSONBase: kubeapi.JSONBase{
 			ID: "foo",
 		},
 		Input: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://my.build.com/the/build/Dockerfile",
-			ImageTag:  "repository/dataBuild",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			ImageTag: "repository/dataBuild",
 		},
 		Status: api.BuildPending,
 		PodID:  "-the-pod-id",
@@ -77,6 +96,9 @@ func TestEtcdCreateBuild(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if value := buildsCreated.Value(string(api.DockerBuildType)); value != created+1 {
+		t.Errorf("Expected the created build to be counted, got %v", value-created)
+	}
 
 	resp, err := fakeClient.Get("/registry/builds/foo", false, false)
 	if err != nil {
@@ -114,6 +136,81 @@ func TestEtcdCreateBuildAlreadyExisting(t *testing.T) {
 	}
 }
 
+func TestEtcdUpdateBuild(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/builds/foo", runtime.EncodeOrDie(api.Build{JSONBase: kubeapi.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdateBuild(&api.Build{
+		JSONBase: kubeapi.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+		Status:   api.BuildRunning,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	build, err := registry.GetBuild("foo")
+	if err != nil || build.Status != api.BuildRunning {
+		t.Errorf("Unexpected build: %#v (%v)", build, err)
+	}
+	if status := build.Labels[api.BuildStatusLabel]; status != string(api.BuildRunning) {
+		t.Errorf("Expected the build to be labeled with its status, got %q", status)
+	}
+}
+
+func TestEtcdUpdateBuildConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/builds/foo", runtime.EncodeOrDie(api.Build{JSONBase: kubeapi.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	stale := &api.Build{JSONBase: kubeapi.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex}}
+	fakeClient.Set("/registry/builds/foo", runtime.EncodeOrDie(api.Build{JSONBase: kubeapi.JSONBase{ID: "foo"}, Cancelled: true}), 0)
+
+	err := registry.UpdateBuild(stale)
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+	if err := registry.UpdateBuild(&api.Build{JSONBase: kubeapi.JSONBase{ID: "foo"}}); !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error for a build without resource version, got %#v", err)
+	}
+}
+
+func TestEtcdUpdateBuildConfigConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/build-configs/foo", runtime.EncodeOrDie(api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	stale := &api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex}, LastVersion: 1}
+	fakeClient.Set("/registry/build-configs/foo", runtime.EncodeOrDie(api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "foo"}, LastVersion: 1}), 0)
+
+	if err := registry.UpdateBuildConfig(stale); !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+}
+
+func TestEtcdRedeemBuildCredentialsToken(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	registry := NewTestEtcdRegistry(fakeClient)
+	if err := registry.SetBuildCredentialsToken("foo", "0123abcd", 60); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, token := range []string{"", "4567ef", redeemedCredentialsToken} {
+		if redeemed, err := registry.RedeemBuildCredentialsToken("foo", token); err != nil || redeemed {
+			t.Errorf("Expected token %q to be refused, got %v (%v)", token, redeemed, err)
+		}
+	}
+	if redeemed, err := registry.RedeemBuildCredentialsToken("foo", "0123abcd"); err != nil || !redeemed {
+		t.Errorf("Expected the token to be redeemed, got %v (%v)", redeemed, err)
+	}
+	if redeemed, err := registry.RedeemBuildCredentialsToken("foo", "0123abcd"); err != nil || redeemed {
+		t.Errorf("Expected the token to be redeemed only once, got %v (%v)", redeemed, err)
+	}
+	if redeemed, err := registry.RedeemBuildCredentialsToken("bar", "0123abcd"); err != nil || redeemed {
+		t.Errorf("Expected the token of another build to be refused, got %v (%v)", redeemed, err)
+	}
+}
+
 func TestEtcdDeleteBuild(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.TestIndex = true
@@ -190,6 +287,43 @@ func TestEtcdListBuilds(t *testing.T) {
 	}
 }
 
+func TestEtcdListBuildsByConfig(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	key := "/registry/builds"
+	fakeClient.Data[key] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Nodes: []*etcd.Node{
+					{
+						Value: runtime.EncodeOrDie(api.Build{
+							JSONBase: kubeapi.JSONBase{ID: "myapp-1"},
+							Labels:   map[string]string{api.BuildConfigLabel: "myapp"},
+							Config:   &api.BuildConfigReference{ID: "myapp", Version: 1},
+						}),
+					},
+					{
+						Value: runtime.EncodeOrDie(api.Build{
+							JSONBase: kubeapi.JSONBase{ID: "other-1"},
+							Labels:   map[string]string{api.BuildConfigLabel: "other"},
+							Config:   &api.BuildConfigReference{ID: "other", Version: 1},
+						}),
+					},
+				},
+			},
+		},
+		E: nil,
+	}
+	registry := NewTestEtcdRegistry(fakeClient)
+	builds, err := registry.ListBuilds(labels.SelectorFromSet(labels.Set{api.BuildConfigLabel: "myapp"}))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(builds.Items) != 1 || builds.Items[0].ID != "myapp-1" || builds.Items[0].Config.Version != 1 {
+		t.Errorf("Unexpected build list: %#v", builds)
+	}
+}
+
 func TestEtcdGetBuildConfig(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.Set("/registry/build-configs/foo", runtime.EncodeOrDie(api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "foo"}}), 0)
@@ -232,9 +366,9 @@ func TestEtcdCreateBuildConfig(t *testing.T) {
 			ID: "foo",
 		},
 		DesiredInput: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://my.build.com/the/build/Dockerfile",
-			ImageTag:  "repository/dataBuild",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			ImageTag: "repository/dataBuild",
 		},
 		Labels: map[string]string{
 			"name": "dataBuildConfig",
@@ -355,3 +489,43 @@ func TestEtcdListBuildConfigs(t *testing.T) {
 		t.Errorf("Unexpected buildConfig list: %#v", buildConfigs)
 	}
 }
+
+func TestEtcdWatchBuilds(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := NewTestEtcdRegistry(fakeClient)
+	filterFields := labels.SelectorFromSet(labels.Set{"ID": "foo"})
+
+	watching, err := registry.WatchBuilds(1, func(build *api.Build) bool {
+		fields := labels.Set{
+			"ID": build.ID,
+		}
+		return filterFields.Matches(fields)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+
+	build := &api.Build{JSONBase: kubeapi.JSONBase{ID: "foo"}}
+	buildBytes, _ := runtime.Codec.Encode(build)
+	fakeClient.WatchResponse <- &etcd.Response{
+		Action: "set",
+		Node: &etcd.Node{
+			Value: string(buildBytes),
+		},
+	}
+
+	event := <-watching.ResultChan()
+	if e, a := watch.Added, event.Type; e != a {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if e, a := build, event.Object; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+
+	fakeClient.WatchInjectError <- nil
+	if _, ok := <-watching.ResultChan(); ok {
+		t.Errorf("watching channel should be closed")
+	}
+	watching.Stop()
+}
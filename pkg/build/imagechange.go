@@ -0,0 +1,186 @@
+package build
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	osclient "github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+// ImageChangeController watches ImageRepositories and creates a new build of
+// every BuildConfig whose builder or base image is a tag that moved to a new image.
+// A BuildConfig is not rebuilt when its own output moves, which would rebuild it
+// forever.
+type ImageChangeController struct {
+	osClient       osclient.Interface
+	dockerRegistry string
+	// tags holds the image id last seen for every tag of every repository
+	tags map[string]map[string]string
+}
+
+// NewImageChangeController creates a new image change controller. dockerRegistry
+// is the registry builds push their output to, as given to the build controller.
+func NewImageChangeController(oc osclient.Interface, dockerRegistry string) *ImageChangeController {
+	return &ImageChangeController{osClient: oc, dockerRegistry: dockerRegistry}
+}
+
+// Run begins watching ImageRepositories, until stop is closed.
+func (c *ImageChangeController) Run(period time.Duration, stop <-chan struct{}) {
+	resourceVersion := uint64(0)
+	go buildutil.Until(func() { c.watchImageRepositories(&resourceVersion, stop) }, period, stop)
+}
+
+// watchImageRepositories reacts to changes of ImageRepositories. The first call
+// records the current tags of all repositories, so that only tags which move
+// after the controller has started trigger builds. It returns once stop is
+// closed.
+func (c *ImageChangeController) watchImageRepositories(resourceVersion *uint64, stop <-chan struct{}) {
+	if c.tags == nil {
+		repos, err := c.osClient.ListImageRepositories(labels.Everything())
+		if err != nil {
+			glog.Errorf("Error listing image repositories: %v", err)
+			time.Sleep(5 * time.Second)
+			return
+		}
+		c.tags = make(map[string]map[string]string)
+		for i := range repos.Items {
+			c.tags[repos.Items[i].ID] = copyTags(repos.Items[i].Tags)
+		}
+		*resourceVersion = repos.ResourceVersion + 1
+	}
+
+	watching, err := c.osClient.WatchImageRepositories(
+		labels.Everything(),
+		labels.Everything(),
+		*resourceVersion,
+	)
+	if err != nil {
+		glog.Errorf("Unexpected failure to watch image repositories: %v", err)
+		time.Sleep(5 * time.Second)
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			watching.Stop()
+			return
+		case event, open := <-watching.ResultChan():
+			if !open {
+				return
+			}
+			repo, ok := event.Object.(*imageapi.ImageRepository)
+			if !ok {
+				glog.Errorf("Unexpected object during image repository watch: %#v", event.Object)
+				continue
+			}
+			// If we get disconnected, start where we left off.
+			*resourceVersion = repo.ResourceVersion + 1
+			if event.Type == watch.Deleted {
+				delete(c.tags, repo.ID)
+				continue
+			}
+			c.syncImageRepository(repo)
+		}
+	}
+}
+
+// syncImageRepository triggers builds for every tag of the repository that
+// points to a different image than when it was last seen.
+func (c *ImageChangeController) syncImageRepository(repo *imageapi.ImageRepository) {
+	previous := c.tags[repo.ID]
+	c.tags[repo.ID] = copyTags(repo.Tags)
+
+	changed := []string{}
+	for tag, imageID := range repo.Tags {
+		if previous[tag] != imageID {
+			changed = append(changed, tag)
+		}
+	}
+	sort.Strings(changed)
+	for _, tag := range changed {
+		c.triggerBuilds(repo, tag)
+	}
+}
+
+// triggerBuilds creates a new build of every BuildConfig using the given tag of
+// the repository as builder or base image.
+func (c *ImageChangeController) triggerBuilds(repo *imageapi.ImageRepository, tag string) {
+	if len(repo.DockerImageRepository) == 0 {
+		return
+	}
+	configs, err := c.osClient.ListBuildConfigs(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error listing build configs: %v", err)
+		return
+	}
+	for i := range configs.Items {
+		config := &configs.Items[i]
+		if !usesImage(&config.DesiredInput, repo.DockerImageRepository, tag) {
+			continue
+		}
+		if r, t := outputImageRepository(c.dockerRegistry, config.DesiredInput.ImageTag); r == repo.DockerImageRepository && t == tag {
+			glog.Warningf("Build config %s uses its own output %s:%s as an image, it is not rebuilt when it changes", config.ID, r, t)
+			continue
+		}
+		build, err := buildutil.LinkBuild(buildutil.NewClientConfigRegistry(c.osClient), config.ID, func(config *api.BuildConfig, build *api.Build) error {
+			build.ImageChangeCause = &api.ImageChangeCause{
+				ImageRepository: repo.ID,
+				Tag:             tag,
+				ImageID:         repo.Tags[tag],
+			}
+			return nil
+		})
+		if err != nil {
+			glog.Errorf("Error updating build config %s: %v", config.ID, err)
+			continue
+		}
+		if _, err := c.osClient.CreateBuild(build); err != nil {
+			glog.Errorf("Error creating build %s for image change of %s:%s: %v", build.ID, repo.DockerImageRepository, tag, err)
+			continue
+		}
+		glog.Infof("Created build %s, %s:%s now points to image %s", build.ID, repo.DockerImageRepository, tag, repo.Tags[tag])
+	}
+}
+
+// usesImage returns true if the builder, custom builder or base image of the
+// input refers to the given tag of the Docker image repository.
+func usesImage(input *api.BuildInput, repository, tag string) bool {
+	images := []string{input.BuilderImage, input.BaseImage}
+	if input.Custom != nil {
+		images = append(images, input.Custom.Image)
+	}
+	for _, image := range images {
+		if len(image) == 0 {
+			continue
+		}
+		if r, t := parseImageReference(image); r == repository && t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseImageReference splits a Docker image reference into its repository
+// and tag, the tag defaults to latest.
+func parseImageReference(image string) (string, string) {
+	if i := strings.LastIndex(image, ":"); i != -1 && !strings.Contains(image[i+1:], "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	copied := make(map[string]string, len(tags))
+	for tag, imageID := range tags {
+		copied[tag] = imageID
+	}
+	return copied
+}
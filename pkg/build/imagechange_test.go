@@ -0,0 +1,205 @@
+package build
+
+import (
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
+	imageapi "github.com/openshift/origin/pkg/image/api"
+)
+
+type imageChangeOsClient struct {
+	osclient.Fake
+	watcher *watch.FakeWatcher
+	repos   []imageapi.ImageRepository
+	configs []api.BuildConfig
+	created []api.Build
+}
+
+func (c *imageChangeOsClient) ListImageRepositories(selector labels.Selector) (*imageapi.ImageRepositoryList, error) {
+	return &imageapi.ImageRepositoryList{Items: c.repos}, nil
+}
+
+func (c *imageChangeOsClient) WatchImageRepositories(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func (c *imageChangeOsClient) ListBuildConfigs(selector labels.Selector) (*api.BuildConfigList, error) {
+	return &api.BuildConfigList{Items: c.configs}, nil
+}
+
+func (c *imageChangeOsClient) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	for i := range c.configs {
+		if c.configs[i].ID == id {
+			config := c.configs[i]
+			return &config, nil
+		}
+	}
+	return nil, kubeerrors.NewNotFound("buildConfig", id)
+}
+
+func (c *imageChangeOsClient) UpdateBuildConfig(config *api.BuildConfig) (*api.BuildConfig, error) {
+	return config, nil
+}
+
+func (c *imageChangeOsClient) CreateBuild(build *api.Build) (*api.Build, error) {
+	c.created = append(c.created, *build)
+	return build, nil
+}
+
+func mockImageRepository(imageID string) imageapi.ImageRepository {
+	return imageapi.ImageRepository{
+		JSONBase:              kubeapi.JSONBase{ID: "ruby"},
+		DockerImageRepository: "openshift/ruby-20-centos",
+		Tags:                  map[string]string{"latest": imageID},
+	}
+}
+
+func mockImageChangeConfigs() []api.BuildConfig {
+	return []api.BuildConfig{
+		{
+			JSONBase: kubeapi.JSONBase{ID: "sti-app"},
+			DesiredInput: api.BuildInput{
+				Type:         api.STIBuildType,
+				BuilderImage: "openshift/ruby-20-centos",
+			},
+		},
+		{
+			JSONBase: kubeapi.JSONBase{ID: "docker-app"},
+			DesiredInput: api.BuildInput{
+				Type:      api.DockerBuildType,
+				BaseImage: "openshift/ruby-20-centos:latest",
+			},
+		},
+		{
+			JSONBase: kubeapi.JSONBase{ID: "other-tag"},
+			DesiredInput: api.BuildInput{
+				Type:         api.STIBuildType,
+				BuilderImage: "openshift/ruby-20-centos:1.0",
+			},
+		},
+		{
+			JSONBase: kubeapi.JSONBase{ID: "other-image"},
+			DesiredInput: api.BuildInput{
+				Type:         api.STIBuildType,
+				BuilderImage: "openshift/python-33-centos",
+			},
+		},
+	}
+}
+
+func TestImageChangeTriggersBuilds(t *testing.T) {
+	client := &imageChangeOsClient{
+		watcher: watch.NewFake(),
+		repos:   []imageapi.ImageRepository{mockImageRepository("image1")},
+		configs: mockImageChangeConfigs(),
+	}
+	controller := NewImageChangeController(client, "")
+	resourceVersion := uint64(0)
+	done := make(chan struct{})
+	go func() {
+		controller.watchImageRepositories(&resourceVersion, nil)
+		close(done)
+	}()
+
+	repo := mockImageRepository("image2")
+	repo.ResourceVersion = 10
+	client.watcher.Modify(&repo)
+	client.watcher.Stop()
+	<-done
+
+	if len(client.created) != 2 {
+		t.Fatalf("Expected 2 builds, got %#v", client.created)
+	}
+	for i, id := range []string{"sti-app-1", "docker-app-1"} {
+		build := client.created[i]
+		if build.ID != id {
+			t.Errorf("Expected build %s, got %s", id, build.ID)
+		}
+		cause := build.ImageChangeCause
+		if cause == nil || cause.ImageID != "image2" || cause.ImageRepository != "ruby" || cause.Tag != "latest" {
+			t.Errorf("Unexpected image change cause of build %s: %#v", build.ID, cause)
+		}
+	}
+	if resourceVersion != 11 {
+		t.Errorf("Expected resource version 11, got %d", resourceVersion)
+	}
+}
+
+func TestImageChangeIgnoresUnchangedTags(t *testing.T) {
+	client := &imageChangeOsClient{
+		watcher: watch.NewFake(),
+		repos:   []imageapi.ImageRepository{mockImageRepository("image1")},
+		configs: mockImageChangeConfigs(),
+	}
+	controller := NewImageChangeController(client, "")
+	resourceVersion := uint64(0)
+	done := make(chan struct{})
+	go func() {
+		controller.watchImageRepositories(&resourceVersion, nil)
+		close(done)
+	}()
+
+	repo := mockImageRepository("image1")
+	repo.Labels = map[string]string{"changed": "true"}
+	client.watcher.Modify(&repo)
+	client.watcher.Stop()
+	<-done
+
+	if len(client.created) != 0 {
+		t.Errorf("Expected no builds, got %#v", client.created)
+	}
+}
+
+func TestImageChangeSkipsOwnOutput(t *testing.T) {
+	configs := []api.BuildConfig{
+		{
+			JSONBase: kubeapi.JSONBase{ID: "self"},
+			DesiredInput: api.BuildInput{
+				Type:      api.DockerBuildType,
+				BaseImage: "registry:5000/app",
+				ImageTag:  "app:latest",
+			},
+		},
+		{
+			JSONBase: kubeapi.JSONBase{ID: "downstream"},
+			DesiredInput: api.BuildInput{
+				Type:      api.DockerBuildType,
+				BaseImage: "registry:5000/app",
+				ImageTag:  "downstream",
+			},
+		},
+	}
+	client := &imageChangeOsClient{configs: configs}
+	controller := NewImageChangeController(client, "registry:5000")
+	repo := imageapi.ImageRepository{
+		JSONBase:              kubeapi.JSONBase{ID: "app"},
+		DockerImageRepository: "registry:5000/app",
+		Tags:                  map[string]string{"latest": "image1"},
+	}
+
+	controller.triggerBuilds(&repo, "latest")
+
+	if len(client.created) != 1 || client.created[0].ID != "downstream-1" {
+		t.Errorf("Expected a single build of downstream, got %#v", client.created)
+	}
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := map[string][2]string{
+		"openshift/ruby":                 {"openshift/ruby", "latest"},
+		"openshift/ruby:2.0":             {"openshift/ruby", "2.0"},
+		"registry:5000/openshift/ruby":   {"registry:5000/openshift/ruby", "latest"},
+		"registry:5000/openshift/ruby:v": {"registry:5000/openshift/ruby", "v"},
+	}
+	for image, expected := range tests {
+		repository, tag := parseImageReference(image)
+		if repository != expected[0] || tag != expected[1] {
+			t.Errorf("%s: expected %s and %s, got %s and %s", image, expected[0], expected[1], repository, tag)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package build
+
+import (
+	"time"
+
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	"github.com/openshift/origin/pkg/metrics"
+)
+
+var (
+	buildsCreated = metrics.NewCounter("openshift_builds_created_total",
+		"Number of builds created, by build type.", "type")
+	buildsCompleted = metrics.NewCounter("openshift_builds_completed_total",
+		"Number of builds which reached a terminal status, by status.", "status")
+	buildDuration = metrics.NewHistogram("openshift_build_duration_seconds",
+		"Time builds spent running, by terminal status.", metrics.ExponentialBuckets(10, 2, 10), "status")
+	buildQueueWait = metrics.NewHistogram("openshift_build_queue_wait_seconds",
+		"Time from the creation of builds until they were allowed to start.", metrics.ExponentialBuckets(1, 2, 14))
+	buildPodCreationErrors = metrics.NewCounter("openshift_build_pod_creation_errors_total",
+		"Number of build pods which could not be created, by reason.", "reason")
+	buildSyncDuration = metrics.NewHistogram("openshift_build_controller_sync_duration_seconds",
+		"Latency of the build controller syncing a single build, the running builds or all builds.", metrics.ExponentialBuckets(0.001, 4, 10), "loop")
+)
+
+func init() {
+	metrics.DefaultRegistry.Register(buildsCreated, buildsCompleted, buildDuration, buildQueueWait, buildPodCreationErrors, buildSyncDuration)
+}
+
+// recordTransitionMetrics records the metrics of a stored change of build from original
+func recordTransitionMetrics(original, build *api.Build) {
+	if original.Status == build.Status {
+		return
+	}
+	if build.Status == api.BuildPending && (original.Status == api.BuildNew || original.Status == api.BuildQueued) {
+		buildQueueWait.Observe(time.Since(build.CreationTimestamp.Time).Seconds())
+	}
+	if buildutil.IsBuildComplete(build) {
+		buildsCompleted.Inc(string(build.Status))
+		if !build.StartTimestamp.IsZero() {
+			buildDuration.Observe(build.Duration.Seconds(), string(build.Status))
+		}
+	}
+}
+
+// observeSince records the time elapsed since start in histogram
+func observeSince(histogram *metrics.Histogram, start time.Time, labelValues ...string) {
+	histogram.Observe(time.Since(start).Seconds(), labelValues...)
+}
@@ -0,0 +1,91 @@
+package build
+
+import (
+	"time"
+
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// legacyBuildLabel is the label which identified build pods before
+// api.BuildLabel, pods created by earlier versions are still reaped by it.
+const legacyBuildLabel = "build"
+
+// BuildPodReaper deletes the pods of builds which finished more than a TTL
+// ago, and the pods of builds which no longer exist. Build pods are recognized
+// by their api.BuildLabel or legacyBuildLabel label together with the pod id
+// given to build pods.
+type BuildPodReaper struct {
+	osClient   osclient.Interface
+	kubeClient kubeclient.Interface
+	ttl        time.Duration
+}
+
+// NewBuildPodReaper creates a new build pod reaper. The pod of a finished
+// build is kept for ttl, so that the logs of the build remain available.
+func NewBuildPodReaper(kc kubeclient.Interface, oc osclient.Interface, ttl time.Duration) *BuildPodReaper {
+	return &BuildPodReaper{
+		osClient:   oc,
+		kubeClient: kc,
+		ttl:        ttl,
+	}
+}
+
+// Run reaps build pods immediately and then every period, until stop is closed.
+func (r *BuildPodReaper) Run(period time.Duration, stop <-chan struct{}) {
+	go buildutil.Until(r.reap, period, stop)
+}
+
+// reap deletes the build pods which are no longer needed.
+func (r *BuildPodReaper) reap() {
+	// pods are listed before builds, so that the build of every pod listed
+	// already exists when builds are listed
+	pods, err := r.kubeClient.ListPods(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error listing pods: %v", err)
+		return
+	}
+	builds, err := r.osClient.ListBuilds(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error listing builds: %v", err)
+		return
+	}
+	buildsByID := make(map[string]*api.Build, len(builds.Items))
+	for i := range builds.Items {
+		buildsByID[builds.Items[i].ID] = &builds.Items[i]
+	}
+
+	for _, pod := range pods.Items {
+		buildID, ok := pod.Labels[api.BuildLabel]
+		if !ok {
+			buildID, ok = pod.Labels[legacyBuildLabel]
+		}
+		if !ok || !isBuildPodID(pod.ID, buildID) {
+			continue
+		}
+		build, exists := buildsByID[buildID]
+		switch {
+		case !exists:
+			glog.Infof("Deleting pod %s of deleted build ID %v", pod.ID, buildID)
+		case r.isExpired(build):
+			glog.Infof("Deleting pod %s of build ID %v, which finished at %v", pod.ID, buildID, build.CompletionTimestamp)
+		default:
+			continue
+		}
+		if err := r.kubeClient.DeletePod(pod.ID); err != nil && !isNotFound(err) {
+			glog.Errorf("Error deleting pod %s of build ID %v: %v", pod.ID, buildID, err)
+		}
+	}
+}
+
+// isExpired returns true if the build finished more than the TTL ago.
+func (r *BuildPodReaper) isExpired(build *api.Build) bool {
+	if !buildutil.IsBuildComplete(build) || build.CompletionTimestamp.IsZero() {
+		return false
+	}
+	return time.Since(build.CompletionTimestamp.Time) > r.ttl
+}
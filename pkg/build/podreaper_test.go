@@ -0,0 +1,104 @@
+package build
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+func labeledPod(id, buildID string) kubeapi.Pod {
+	pod := kubeapi.Pod{JSONBase: kubeapi.JSONBase{ID: id}}
+	if len(buildID) > 0 {
+		pod.Labels = map[string]string{api.BuildLabel: buildID}
+	}
+	return pod
+}
+
+func deletedPods(client *kubeclient.Fake) []string {
+	deleted := []string{}
+	for _, action := range client.Actions {
+		if action.Action == "delete-pod" {
+			deleted = append(deleted, action.Value.(string))
+		}
+	}
+	sort.Strings(deleted)
+	return deleted
+}
+
+func TestReapBuildPods(t *testing.T) {
+	finished := func(id string, status api.BuildStatus, age time.Duration) api.Build {
+		build := api.Build{JSONBase: kubeapi.JSONBase{ID: id}, Status: status}
+		build.CompletionTimestamp.Time = time.Now().Add(-age)
+		return build
+	}
+	osClient := &watchOsClient{builds: []api.Build{
+		{JSONBase: kubeapi.JSONBase{ID: "running"}, Status: api.BuildRunning},
+		finished("recent", api.BuildComplete, time.Minute),
+		finished("expired", api.BuildFailed, 2*time.Hour),
+		finished("expiredError", api.BuildError, 2*time.Hour),
+	}}
+	kubeClient := &kubeclient.Fake{Pods: kubeapi.PodList{Items: []kubeapi.Pod{
+		labeledPod("build-docker-running", "running"),
+		labeledPod("build-docker-recent", "recent"),
+		labeledPod("build-docker-expired", "expired"),
+		labeledPod("build-docker-expiredError", "expiredError"),
+		labeledPod("build-docker-deleted", "deleted"),
+		{JSONBase: kubeapi.JSONBase{ID: "build-sti-legacy"}, Labels: map[string]string{"build": "legacy"}},
+		labeledPod("frontend", ""),
+	}}}
+	reaper := NewBuildPodReaper(kubeClient, osClient, time.Hour)
+
+	reaper.reap()
+
+	expected := []string{"build-docker-deleted", "build-docker-expired", "build-docker-expiredError", "build-sti-legacy"}
+	if actual := deletedPods(kubeClient); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected pods %v to be deleted, got %v", expected, actual)
+	}
+}
+
+func TestReapBuildPodsIgnoresOtherPods(t *testing.T) {
+	osClient := &watchOsClient{}
+	kubeClient := &kubeclient.Fake{Pods: kubeapi.PodList{Items: []kubeapi.Pod{
+		{JSONBase: kubeapi.JSONBase{ID: "frontend"}, Labels: map[string]string{"build": "deleted"}},
+		labeledPod("frontend", "deleted"),
+		labeledPod("build-docker-deleted-cache", "deleted"),
+		labeledPod("build--deleted", "deleted"),
+	}}}
+	reaper := NewBuildPodReaper(kubeClient, osClient, time.Hour)
+
+	reaper.reap()
+
+	if deleted := deletedPods(kubeClient); len(deleted) != 0 {
+		t.Errorf("Expected no pods to be deleted, got %v", deleted)
+	}
+}
+
+type errListOsClient struct {
+	osclient.Fake
+}
+
+func (_ *errListOsClient) ListBuilds(selector labels.Selector) (*api.BuildList, error) {
+	return nil, errors.New("ListBuilds error!")
+}
+
+func TestReapBuildPodsListBuildsError(t *testing.T) {
+	kubeClient := &kubeclient.Fake{Pods: kubeapi.PodList{Items: []kubeapi.Pod{
+		labeledPod("build-docker-deleted", "deleted"),
+	}}}
+	reaper := NewBuildPodReaper(kubeClient, &errListOsClient{}, time.Hour)
+
+	reaper.reap()
+
+	// nothing may be deleted when it is unknown which builds exist
+	if deleted := deletedPods(kubeClient); len(deleted) != 0 {
+		t.Errorf("Expected no pods to be deleted, got %v", deleted)
+	}
+}
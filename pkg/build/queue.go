@@ -0,0 +1,142 @@
+package build
+
+import (
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// admit decides whether a new or queued build may start running. It returns 0
+// if the build may start, or its 1-based position in the queue otherwise. Builds
+// synced by synchronizeAll and synchronizeWaiting use the positions computed for
+// the whole pass instead, see syncWaiting, so that the builds are only listed to
+// admit a new build observed by the watch.
+func (bc *BuildController) admit(build *api.Build) (int, error) {
+	if position, ok := bc.queue[build.ID]; ok {
+		return position, nil
+	}
+	if bc.maxConcurrentBuilds <= 0 && (build.Config == nil || bc.configLimit(build.Config.ID, map[string]int{}) <= 0) {
+		return 0, nil
+	}
+
+	builds, err := bc.osClient.ListBuilds(buildutil.ActiveBuildsSelector())
+	if err != nil {
+		return 0, err
+	}
+	items := []api.Build{*build}
+	for _, b := range builds.Items {
+		if b.ID != build.ID {
+			items = append(items, b)
+		}
+	}
+	return bc.queuePositions(items)[build.ID], nil
+}
+
+// queuePositions computes in one pass the queue position of every waiting build
+// of builds. Builds which have been admitted (pending or running) count against
+// the global limit of the controller and against the limit of their BuildConfig.
+// Waiting builds are admitted in FIFO order: a build starts only if it still fits
+// once every build ahead of it that fits has been started. The position of a
+// build which may start is 0.
+func (bc *BuildController) queuePositions(builds []api.Build) map[string]int {
+	limits := map[string]int{}
+	active := 0
+	activePerConfig := map[string]int{}
+	waiting := []*api.Build{}
+	for i := range builds {
+		b := &builds[i]
+		switch {
+		case b.Status == api.BuildPending || b.Status == api.BuildRunning:
+			active++
+			if b.Config != nil {
+				activePerConfig[b.Config.ID]++
+			}
+		case isWaiting(b):
+			waiting = append(waiting, b)
+		}
+	}
+	sort.Sort(byQueueOrder(waiting))
+
+	positions := map[string]int{}
+	position := 0
+	for _, b := range waiting {
+		configID, limit := "", 0
+		if b.Config != nil {
+			configID = b.Config.ID
+			limit = bc.configLimit(configID, limits)
+		}
+		fits := (bc.maxConcurrentBuilds <= 0 || active < bc.maxConcurrentBuilds) &&
+			(limit <= 0 || activePerConfig[configID] < limit)
+		if fits {
+			positions[b.ID] = 0
+			active++
+			if len(configID) > 0 {
+				activePerConfig[configID]++
+			}
+			continue
+		}
+		position++
+		positions[b.ID] = position
+	}
+	return positions
+}
+
+// syncWaiting syncs the waiting builds among builds in FIFO order, admitting
+// them according to the queue positions computed once for all of them.
+func (bc *BuildController) syncWaiting(builds []api.Build) {
+	bc.queue = bc.queuePositions(builds)
+	defer func() { bc.queue = nil }()
+
+	waiting := []*api.Build{}
+	for i := range builds {
+		if isWaiting(&builds[i]) {
+			waiting = append(waiting, &builds[i])
+		}
+	}
+	sort.Sort(byQueueOrder(waiting))
+	for _, build := range waiting {
+		bc.syncBuild(build)
+	}
+}
+
+// configLimit returns the maximum number of concurrent builds of the given
+// BuildConfig, caching the result in limits. A BuildConfig which cannot be
+// retrieved does not limit its builds.
+func (bc *BuildController) configLimit(id string, limits map[string]int) int {
+	if limit, ok := limits[id]; ok {
+		return limit
+	}
+	limit := 0
+	if config, err := bc.osClient.GetBuildConfig(id); err != nil {
+		glog.Errorf("Error retrieving build config %s, not limiting its builds: %v", id, err)
+	} else {
+		limit = config.MaxConcurrentBuilds
+	}
+	limits[id] = limit
+	return limit
+}
+
+// isWaiting returns true if the build has not been admitted yet.
+func isWaiting(build *api.Build) bool {
+	return (build.Status == api.BuildNew || build.Status == api.BuildQueued) && !build.Cancelled
+}
+
+// byQueueOrder sorts builds in the order they were created. Builds created
+// within the same second are ordered by their number within the BuildConfig
+// they belong to, or else by their ID.
+type byQueueOrder []*api.Build
+
+func (q byQueueOrder) Len() int      { return len(q) }
+func (q byQueueOrder) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q byQueueOrder) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if !a.CreationTimestamp.Equal(b.CreationTimestamp.Time) {
+		return a.CreationTimestamp.Before(b.CreationTimestamp.Time)
+	}
+	if a.Config != nil && b.Config != nil && a.Config.ID == b.Config.ID {
+		return a.Config.Version < b.Config.Version
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,314 @@
+package build
+
+import (
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// queueOsClient stores builds in memory so that updates made while syncing
+// are visible to later listings. It counts the listings of active builds.
+type queueOsClient struct {
+	osclient.Fake
+	watcher    *watch.FakeWatcher
+	builds     []api.Build
+	configs    map[string]*api.BuildConfig
+	listCalls  int
+	getConfigs int
+}
+
+func (c *queueOsClient) WatchBuilds(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func (c *queueOsClient) ListBuilds(selector labels.Selector) (*api.BuildList, error) {
+	if selector.String() == buildutil.ActiveBuildsSelector().String() {
+		c.listCalls++
+	}
+	builds := []api.Build{}
+	for _, build := range c.builds {
+		buildutil.SetStatusLabels(&build)
+		if selector.Matches(labels.Set(build.Labels)) {
+			builds = append(builds, build)
+		}
+	}
+	return &api.BuildList{Items: builds}, nil
+}
+
+func (c *queueOsClient) UpdateBuild(build *api.Build) (*api.Build, error) {
+	for i := range c.builds {
+		if c.builds[i].ID == build.ID {
+			c.builds[i] = *build
+		}
+	}
+	return build, nil
+}
+
+func (c *queueOsClient) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	c.getConfigs++
+	return c.configs[id], nil
+}
+
+func (c *queueOsClient) build(id string) *api.Build {
+	for i := range c.builds {
+		if c.builds[i].ID == id {
+			return &c.builds[i]
+		}
+	}
+	return nil
+}
+
+func queueBuild(id string, status api.BuildStatus, config string, created int) api.Build {
+	build := api.Build{
+		JSONBase: kubeapi.JSONBase{
+			ID:                id,
+			CreationTimestamp: util.Time{Time: time.Unix(int64(created), 0)},
+		},
+		Input:  api.BuildInput{Type: "okStrategy"},
+		Status: status,
+	}
+	if len(config) > 0 {
+		build.Config = &api.BuildConfigReference{ID: config}
+	}
+	return build
+}
+
+func TestAdmitUnlimited(t *testing.T) {
+	ctrl, build := setup()
+	position, err := ctrl.admit(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if position != 0 {
+		t.Errorf("Expected the build to be admitted, got queue position %d", position)
+	}
+}
+
+func TestAdmitGlobalLimit(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 2
+	client := &queueOsClient{builds: []api.Build{
+		queueBuild("running", api.BuildRunning, "", 1),
+		queueBuild("pending", api.BuildPending, "", 2),
+		queueBuild("complete", api.BuildComplete, "", 3),
+		queueBuild("queued", api.BuildQueued, "", 4),
+		queueBuild("new", api.BuildNew, "", 5),
+	}}
+	ctrl.osClient = client
+
+	for id, expected := range map[string]int{"queued": 1, "new": 2} {
+		position, err := ctrl.admit(client.build(id))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if position != expected {
+			t.Errorf("%s: expected queue position %d, got %d", id, expected, position)
+		}
+	}
+}
+
+func TestAdmitFIFO(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 2
+	client := &queueOsClient{builds: []api.Build{
+		queueBuild("running", api.BuildRunning, "", 1),
+		queueBuild("newer", api.BuildNew, "", 5),
+		queueBuild("older", api.BuildQueued, "", 4),
+	}}
+	ctrl.osClient = client
+
+	if position, _ := ctrl.admit(client.build("older")); position != 0 {
+		t.Errorf("Expected the oldest build to be admitted, got queue position %d", position)
+	}
+	if position, _ := ctrl.admit(client.build("newer")); position != 1 {
+		t.Errorf("Expected the newer build to be queued first, got queue position %d", position)
+	}
+}
+
+func TestAdmitConfigLimit(t *testing.T) {
+	ctrl, _ := setup()
+	client := &queueOsClient{
+		builds: []api.Build{
+			queueBuild("myapp-1", api.BuildRunning, "myapp", 1),
+			queueBuild("myapp-2", api.BuildNew, "myapp", 2),
+			queueBuild("other-1", api.BuildNew, "other", 3),
+		},
+		configs: map[string]*api.BuildConfig{
+			"myapp": {MaxConcurrentBuilds: 1},
+			"other": {MaxConcurrentBuilds: 1},
+		},
+	}
+	ctrl.osClient = client
+
+	if position, _ := ctrl.admit(client.build("myapp-2")); position != 1 {
+		t.Errorf("Expected myapp-2 to be queued, got queue position %d", position)
+	}
+	if position, _ := ctrl.admit(client.build("other-1")); position != 0 {
+		t.Errorf("Expected other-1 to be admitted, got queue position %d", position)
+	}
+}
+
+func TestByQueueOrderSameSecond(t *testing.T) {
+	first := queueBuild("myapp-9", api.BuildNew, "myapp", 1)
+	first.Config.Version = 9
+	second := queueBuild("myapp-10", api.BuildNew, "myapp", 1)
+	second.Config.Version = 10
+	if !(byQueueOrder{&first, &second}).Less(0, 1) {
+		t.Errorf("Expected builds of the same config to be ordered by version")
+	}
+}
+
+func TestSynchronizeBuildNewQueued(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 1
+	client := &queueOsClient{builds: []api.Build{
+		queueBuild("running", api.BuildRunning, "", 1),
+		queueBuild("new", api.BuildNew, "", 2),
+	}}
+	ctrl.osClient = client
+	build := client.build("new")
+
+	status, err := ctrl.synchronize(build)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if status != api.BuildQueued {
+		t.Errorf("Expected BuildQueued, got %s!", status)
+	}
+	if build.QueuePosition != 1 {
+		t.Errorf("Expected queue position 1, got %d", build.QueuePosition)
+	}
+	if len(build.PodID) != 0 {
+		t.Errorf("Expected no pod to be assigned, got %s", build.PodID)
+	}
+}
+
+func TestSynchronizeAllReleasesQueuedBuild(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 1
+	ctrl.kubeClient = &okKubeClient{}
+	client := &queueOsClient{builds: []api.Build{
+		queueBuild("queued", api.BuildQueued, "", 2),
+		queueBuild("running", api.BuildRunning, "", 1),
+	}}
+	client.builds[0].QueuePosition = 1
+	ctrl.osClient = client
+
+	ctrl.synchronizeAll()
+
+	if status := client.build("running").Status; status != api.BuildComplete {
+		t.Errorf("Expected the running build to complete, got %s", status)
+	}
+	queued := client.build("queued")
+	if queued.Status != api.BuildPending || queued.QueuePosition != 0 {
+		t.Errorf("Expected the queued build to be released, got %s at position %d", queued.Status, queued.QueuePosition)
+	}
+}
+
+func TestSynchronizeRunningReleasesQueuedBuild(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 1
+	ctrl.kubeClient = &okKubeClient{}
+	client := &queueOsClient{builds: []api.Build{
+		queueBuild("queued", api.BuildQueued, "", 2),
+		queueBuild("running", api.BuildRunning, "", 1),
+		queueBuild("new", api.BuildNew, "", 3),
+	}}
+	client.builds[0].QueuePosition = 1
+	client.builds[2].QueuePosition = 2
+	ctrl.osClient = client
+
+	ctrl.synchronizeRunning()
+
+	if status := client.build("running").Status; status != api.BuildComplete {
+		t.Errorf("Expected the running build to complete, got %s", status)
+	}
+	if queued := client.build("queued"); queued.Status != api.BuildPending {
+		t.Errorf("Expected the queued build to be released, got %s", queued.Status)
+	}
+	if build := client.build("new"); build.Status != api.BuildQueued || build.QueuePosition != 1 {
+		t.Errorf("Expected the new build to move up the queue, got %s at position %d", build.Status, build.QueuePosition)
+	}
+}
+
+func TestSynchronizeAllComputesQueueOnce(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 2
+	ctrl.kubeClient = &okKubeClient{}
+	client := &queueOsClient{
+		builds: []api.Build{
+			queueBuild("running", api.BuildRunning, "", 1),
+			queueBuild("myapp-1", api.BuildNew, "myapp", 2),
+			queueBuild("myapp-2", api.BuildNew, "myapp", 3),
+			queueBuild("myapp-3", api.BuildQueued, "myapp", 4),
+			queueBuild("other-1", api.BuildNew, "other", 5),
+		},
+		configs: map[string]*api.BuildConfig{
+			"myapp": {MaxConcurrentBuilds: 1},
+			"other": {},
+		},
+	}
+	ctrl.osClient = client
+
+	ctrl.synchronizeAll()
+
+	if client.listCalls != 1 {
+		t.Errorf("Expected the active builds to be listed once, got %d listings", client.listCalls)
+	}
+	if client.getConfigs != 2 {
+		t.Errorf("Expected every build config to be read once, got %d reads", client.getConfigs)
+	}
+	// the running build completes and frees a slot for myapp-1 and other-1
+	expected := map[string]struct {
+		status   api.BuildStatus
+		position int
+	}{
+		"myapp-1": {api.BuildPending, 0},
+		"myapp-2": {api.BuildQueued, 1},
+		"myapp-3": {api.BuildQueued, 2},
+		"other-1": {api.BuildPending, 0},
+	}
+	for id, e := range expected {
+		build := client.build(id)
+		if build.Status != e.status || build.QueuePosition != e.position {
+			t.Errorf("%s: expected %s at position %d, got %s at position %d", id, e.status, e.position, build.Status, build.QueuePosition)
+		}
+	}
+}
+
+func TestWatchBuildsSkipsQueuedBuilds(t *testing.T) {
+	ctrl, _ := setup()
+	ctrl.maxConcurrentBuilds = 1
+	client := &queueOsClient{
+		watcher: watch.NewFake(),
+		builds: []api.Build{
+			queueBuild("running", api.BuildRunning, "", 1),
+			queueBuild("queued-1", api.BuildQueued, "", 2),
+			queueBuild("queued-2", api.BuildQueued, "", 3),
+		},
+	}
+	ctrl.osClient = client
+
+	go func() {
+		// the queue positions written back by a sync are observed by the watch
+		for i := 1; i < len(client.builds); i++ {
+			build := client.builds[i]
+			build.QueuePosition = i
+			client.watcher.Modify(&build)
+		}
+		client.watcher.Stop()
+	}()
+	resourceVersion := uint64(0)
+	ctrl.watchBuilds(&resourceVersion, nil)
+
+	if client.listCalls != 0 {
+		t.Errorf("Expected no listing for the queued builds observed, got %d listings", client.listCalls)
+	}
+}
@@ -0,0 +1,52 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/archive"
+)
+
+// ArchiveHandler serves the archives uploaded to start builds to the builder
+// pods.
+type ArchiveHandler struct {
+	store *archive.Store
+}
+
+// NewArchiveHandler creates a new ArchiveHandler.
+func NewArchiveHandler(store *archive.Store) *ArchiveHandler {
+	return &ArchiveHandler{store}
+}
+
+// ServeSubresource streams the archive of the build identified by id. The
+// archive is removed once the build is complete.
+func (h *ArchiveHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "GET" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, err := h.store.Open(id)
+	if os.IsNotExist(err) {
+		writeError(w, errors.NewNotFound("buildArchive", id))
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to read the archive of build %s: %v", id, err), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		glog.Errorf("Error serving the archive of build %s: %v", id, err)
+	}
+}
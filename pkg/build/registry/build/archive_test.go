@@ -0,0 +1,46 @@
+package build
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/origin/pkg/build/archive"
+)
+
+func TestServeArchive(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	if _, err := store.Save("foo-1", strings.NewReader("archive content")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	handler := NewArchiveHandler(store)
+
+	req, _ := http.NewRequest("GET", "/builds/foo-1/archive", nil)
+	w := httptest.NewRecorder()
+	handler.ServeSubresource(w, req, "foo-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != "archive content" {
+		t.Errorf("Unexpected archive content %q", w.Body.String())
+	}
+	if e, a := "application/octet-stream", w.Header().Get("Content-Type"); e != a {
+		t.Errorf("Expected content type %s, got %s", e, a)
+	}
+
+	req, _ = http.NewRequest("GET", "/builds/foo-2/archive", nil)
+	w = httptest.NewRecorder()
+	handler.ServeSubresource(w, req, "foo-2")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/builds/foo-1/archive", nil)
+	w = httptest.NewRecorder()
+	handler.ServeSubresource(w, req, "foo-1")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
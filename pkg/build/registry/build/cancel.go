@@ -0,0 +1,92 @@
+package build
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/apiserver"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// CancelHandler requests cancellation of builds. The build controller stops
+// the build by deleting its pod and moves it to the cancelled status.
+type CancelHandler struct {
+	registry Registry
+}
+
+// NewCancelHandler creates a new CancelHandler.
+func NewCancelHandler(registry Registry) *CancelHandler {
+	return &CancelHandler{registry}
+}
+
+// ServeSubresource marks the build identified by id as cancelled, recording
+// who requested the cancellation and when, and returns the updated build.
+func (h *CancelHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	build, err := h.registry.GetBuild(id)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	if build.Cancelled {
+		apiserver.WriteJSON(http.StatusOK, runtime.Codec, build, w)
+		return
+	}
+	if buildutil.IsBuildComplete(build) {
+		err := errors.NewConflict("build", id, fmt.Errorf("the build has already finished with status %s", build.Status))
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+
+	build.Cancelled = true
+	build.CancelledBy = requester(req)
+	build.CancellationTimestamp = util.Now()
+	if err := h.registry.UpdateBuild(build); err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	glog.Infof("Cancellation of build %s requested by %s", build.ID, build.CancelledBy)
+	apiserver.WriteJSON(http.StatusOK, runtime.Codec, build, w)
+}
+
+// requester identifies the originator of a request by its user name when the
+// request is authenticated, and by its remote host otherwise.
+func requester(req *http.Request) string {
+	if user := basicAuthUser(req); len(user) > 0 {
+		return user
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// basicAuthUser returns the user name of the basic authentication credentials
+// of the request, or an empty string when there are none.
+func basicAuthUser(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	const prefix = "Basic "
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	credentials, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return ""
+	}
+	parts := strings.SplitN(string(credentials), ":", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[0]
+}
@@ -0,0 +1,75 @@
+package build
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+func postCancel(registry Registry, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/builds/dataBuild/cancel", nil)
+	req.RemoteAddr = "10.0.0.1:34567"
+	if len(user) > 0 {
+		req.SetBasicAuth(user, "password")
+	}
+	w := httptest.NewRecorder()
+	NewCancelHandler(registry).ServeSubresource(w, req, "dataBuild")
+	return w
+}
+
+func TestCancelBuild(t *testing.T) {
+	registry := &test.BuildRegistry{Build: mockBuild()}
+	w := postCancel(registry, "jdoe")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := registry.UpdatedBuild
+	if build == nil {
+		t.Fatalf("Expected the build to be updated")
+	}
+	if !build.Cancelled {
+		t.Errorf("Expected the build to be cancelled")
+	}
+	if e, a := "jdoe", build.CancelledBy; e != a {
+		t.Errorf("Expected %s, got %s", e, a)
+	}
+	if build.CancellationTimestamp.IsZero() {
+		t.Errorf("Expected a cancellation timestamp")
+	}
+}
+
+func TestCancelBuildAnonymous(t *testing.T) {
+	registry := &test.BuildRegistry{Build: mockBuild()}
+	postCancel(registry, "")
+	if registry.UpdatedBuild == nil {
+		t.Fatalf("Expected the build to be updated")
+	}
+	if e, a := "10.0.0.1", registry.UpdatedBuild.CancelledBy; e != a {
+		t.Errorf("Expected %s, got %s", e, a)
+	}
+}
+
+func TestCancelCompletedBuild(t *testing.T) {
+	build := mockBuild()
+	build.Status = api.BuildComplete
+	registry := &test.BuildRegistry{Build: build}
+	w := postCancel(registry, "jdoe")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if registry.UpdatedBuild != nil {
+		t.Errorf("Unexpected update of build %#v", registry.UpdatedBuild)
+	}
+}
+
+func TestCancelBuildNotFound(t *testing.T) {
+	registry := &test.BuildRegistry{Err: errors.NewNotFound("build", "dataBuild")}
+	w := postCancel(registry, "jdoe")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,161 @@
+package build
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"code.google.com/p/go-uuid/uuid"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/apiserver"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/api/validation"
+	"github.com/openshift/origin/pkg/build/archive"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// CloneHandler re-runs builds with identical inputs. The BuildConfig a build was
+// created from may have changed since, so the clone copies the input and the
+// revision of the original build instead of instantiating the BuildConfig.
+type CloneHandler struct {
+	registry Registry
+	configs  buildutil.ConfigRegistry
+	store    *archive.Store
+}
+
+// NewCloneHandler creates a new CloneHandler. The clone of a build of an
+// uploaded archive shares the archive kept in store.
+func NewCloneHandler(registry Registry, configs buildutil.ConfigRegistry, store *archive.Store) *CloneHandler {
+	return &CloneHandler{registry, configs, store}
+}
+
+// ServeSubresource creates a new build from the build identified by id and
+// returns it. The new build is pinned to the commit and builder image the
+// original build resolved, and records the id of the original build.
+func (h *CloneHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	original, err := h.registry.GetBuild(id)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	build, err := h.clone(original)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	glog.Infof("Build %s was cloned from build %s", build.ID, original.ID)
+	apiserver.WriteJSON(http.StatusOK, runtime.Codec, build, w)
+}
+
+// clone creates the copy of original. A copy of a build created from a
+// BuildConfig which still exists is numbered by the BuildConfig.
+func (h *CloneHandler) clone(original *api.Build) (*api.Build, error) {
+	// the archive of a build is removed once the build is complete, a build
+	// whose archive is gone can not be cloned
+	archiveBaseURL, uploaded := uploadedArchiveBaseURL(original)
+	if uploaded {
+		file, err := h.store.Open(original.ID)
+		if os.IsNotExist(err) {
+			return nil, errors.NewNotFound("buildArchive", original.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
+	}
+	prepare := func(build *api.Build) error {
+		cloneBuild(build, original)
+		if uploaded {
+			build.Input.Source.Archive = &api.ArchiveBuildSource{URL: archiveURL(archiveBaseURL, build.ID)}
+		}
+		if errs := validation.ValidateBuild(build); len(errs) > 0 {
+			return errors.NewInvalid("build", build.ID, errs)
+		}
+		return nil
+	}
+
+	var build *api.Build
+	if original.Config != nil {
+		var err error
+		build, err = buildutil.LinkBuild(h.configs, original.Config.ID, func(config *api.BuildConfig, build *api.Build) error {
+			return prepare(build)
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if build == nil {
+		build = &api.Build{Status: api.BuildNew}
+		build.CreationTimestamp = util.Now()
+		build.ID = uuid.NewUUID().String()
+		if err := prepare(build); err != nil {
+			return nil, err
+		}
+	}
+
+	if uploaded {
+		if err := h.store.Link(original.ID, build.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.registry.CreateBuild(build); err != nil {
+		if uploaded {
+			if err := h.store.Remove(build.ID); err != nil {
+				glog.Errorf("Error removing the archive of build %s: %v", build.ID, err)
+			}
+		}
+		return nil, err
+	}
+	return build, nil
+}
+
+// uploadedArchiveBaseURL returns the URL of the API build serves its archive
+// from, if the source of build is an archive uploaded to the master.
+func uploadedArchiveBaseURL(build *api.Build) (string, bool) {
+	source := build.Input.Source
+	if source.Type != api.ArchiveBuildSourceType || source.Archive == nil {
+		return "", false
+	}
+	suffix := archiveURL("", build.ID)
+	if !strings.HasSuffix(source.Archive.URL, suffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(source.Archive.URL, suffix), true
+}
+
+// archiveURL returns the URL of the archive operation of the build id.
+func archiveURL(baseURL, id string) string {
+	return fmt.Sprintf("%s/builds/%s/archive", baseURL, id)
+}
+
+// cloneBuild sets the input, labels and revision of original on build. The
+// labels of original replace the labels build already has, and the git source
+// is pinned to the commit original resolved.
+func cloneBuild(build, original *api.Build) {
+	build.Input = original.Input
+	build.ClonedFrom = original.ID
+	if build.Labels == nil {
+		build.Labels = map[string]string{}
+	}
+	for key, value := range original.Labels {
+		build.Labels[key] = value
+	}
+	if original.Revision != nil {
+		revision := *original.Revision
+		build.Revision = &revision
+		if len(revision.Commit) > 0 && build.Input.Source.Git != nil {
+			git := *build.Input.Source.Git
+			git.Ref = revision.Commit
+			build.Input.Source.Git = &git
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package build
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/archive"
+	"github.com/openshift/origin/pkg/build/registry/test"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+func postClone(registry Registry, configs buildutil.ConfigRegistry) *httptest.ResponseRecorder {
+	return postCloneWithStore(registry, configs, nil)
+}
+
+func postCloneWithStore(registry Registry, configs buildutil.ConfigRegistry, store *archive.Store) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/builds/dataBuild/clone", nil)
+	w := httptest.NewRecorder()
+	NewCloneHandler(registry, configs, store).ServeSubresource(w, req, "dataBuild")
+	return w
+}
+
+func mockUploadedBuild() *api.Build {
+	build := mockResolvedBuild()
+	build.Input.Source = api.BuildSource{
+		Type:    api.ArchiveBuildSourceType,
+		Archive: &api.ArchiveBuildSource{URL: "http://master:8080/osapi/v1beta1/builds/dataBuild/archive"},
+	}
+	return build
+}
+
+func mockResolvedBuild() *api.Build {
+	build := mockBuild()
+	build.Status = api.BuildFailed
+	build.Input.Source.Git.Ref = "master"
+	build.Revision = &api.BuildRevision{Commit: "0123abcd", BuilderImageID: "def456"}
+	build.Config = &api.BuildConfigReference{ID: "dataBuild", Version: 3}
+	build.Labels[api.BuildConfigLabel] = "dataBuild"
+	return build
+}
+
+func TestCloneBuild(t *testing.T) {
+	original := mockResolvedBuild()
+	registry := &test.BuildRegistry{Build: original}
+	configs := &test.BuildConfigRegistry{BuildConfig: &api.BuildConfig{LastVersion: 7}}
+	configs.BuildConfig.ID = "dataBuild"
+
+	w := postClone(registry, configs)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := registry.CreatedBuild
+	if build == nil {
+		t.Fatalf("Expected a build to be created")
+	}
+	if build.ID != "dataBuild-8" || build.Config == nil || build.Config.Version != 8 {
+		t.Errorf("Expected the clone to be numbered by the build config, got %#v", build)
+	}
+	if configs.UpdatedConfig == nil || configs.UpdatedConfig.LastVersion != 8 {
+		t.Errorf("Expected the build counter of the config to be stored, got %#v", configs.UpdatedConfig)
+	}
+	if build.ClonedFrom != original.ID {
+		t.Errorf("Expected the clone to link to %s, got %s", original.ID, build.ClonedFrom)
+	}
+	if build.Status != api.BuildNew || len(build.PodID) != 0 {
+		t.Errorf("Expected a new build, got %#v", build)
+	}
+	if build.Input.Source.Git.Ref != "0123abcd" {
+		t.Errorf("Expected the clone to be pinned to the resolved commit, got %s", build.Input.Source.Git.Ref)
+	}
+	if build.Revision == nil || build.Revision.BuilderImageID != "def456" {
+		t.Errorf("Expected the revision to be copied, got %#v", build.Revision)
+	}
+	if original.Input.Source.Git.Ref != "master" {
+		t.Errorf("Expected the original build not to be modified, got %s", original.Input.Source.Git.Ref)
+	}
+	if build.Labels["name"] != "dataBuild" {
+		t.Errorf("Expected the labels to be copied, got %v", build.Labels)
+	}
+}
+
+func TestCloneBuildWithoutConfig(t *testing.T) {
+	original := mockResolvedBuild()
+	registry := &test.BuildRegistry{Build: original}
+	configs := &test.BuildConfigRegistry{Err: errors.NewNotFound("buildConfig", "dataBuild")}
+
+	w := postClone(registry, configs)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := registry.CreatedBuild
+	if len(build.ID) == 0 || build.ID == original.ID {
+		t.Errorf("Expected a new id, got %s", build.ID)
+	}
+	if configs.UpdatedConfig != nil {
+		t.Errorf("Unexpected update of config %#v", configs.UpdatedConfig)
+	}
+}
+
+func TestCloneBuildNotFound(t *testing.T) {
+	registry := &test.BuildRegistry{Err: errors.NewNotFound("build", "dataBuild")}
+	w := postClone(registry, &test.BuildConfigRegistry{})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if registry.CreatedBuild != nil {
+		t.Errorf("Unexpected creation of build %#v", registry.CreatedBuild)
+	}
+}
+
+func TestCloneUploadedBuild(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	if _, err := store.Save("dataBuild", strings.NewReader("archive content")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	registry := &test.BuildRegistry{Build: mockUploadedBuild()}
+	configs := &test.BuildConfigRegistry{BuildConfig: &api.BuildConfig{LastVersion: 7}}
+	configs.BuildConfig.ID = "dataBuild"
+
+	w := postCloneWithStore(registry, configs, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := registry.CreatedBuild
+	if e, a := "http://master:8080/osapi/v1beta1/builds/dataBuild-8/archive", build.Input.Source.Archive.URL; e != a {
+		t.Errorf("Expected archive URL %s, got %s", e, a)
+	}
+	// the archive of the original build is removed once it is complete
+	if err := store.Remove("dataBuild"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file, err := store.Open("dataBuild-8")
+	if err != nil {
+		t.Fatalf("Expected the clone to keep the archive, got %v", err)
+	}
+	defer file.Close()
+	if content, _ := ioutil.ReadAll(file); string(content) != "archive content" {
+		t.Errorf("Unexpected archive content %q", content)
+	}
+}
+
+func TestCloneUploadedBuildAfterReap(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	if _, err := store.Save("dataBuild", strings.NewReader("archive content")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	original := mockUploadedBuild()
+	original.CompletionTimestamp = util.Now()
+	registry := &test.BuildRegistry{Build: original, Builds: &api.BuildList{Items: []api.Build{*original}}}
+	configs := &test.BuildConfigRegistry{BuildConfig: &api.BuildConfig{LastVersion: 7}}
+	configs.BuildConfig.ID = "dataBuild"
+
+	// the archive of the failed build is retained so that it can be re-run
+	archive.NewReaper(store, registry, time.Hour, 24*time.Hour).Reap()
+	w := postCloneWithStore(registry, configs, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.Open("dataBuild-8"); err != nil {
+		t.Errorf("Expected the clone to keep the archive, got %v", err)
+	}
+}
+
+func TestCloneUploadedBuildArchiveRemoved(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	registry := &test.BuildRegistry{Build: mockUploadedBuild()}
+	configs := &test.BuildConfigRegistry{BuildConfig: &api.BuildConfig{LastVersion: 7}}
+	configs.BuildConfig.ID = "dataBuild"
+
+	w := postCloneWithStore(registry, configs, store)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+	if registry.CreatedBuild != nil || configs.UpdatedConfig != nil {
+		t.Errorf("Expected no build to be created, got %#v", registry.CreatedBuild)
+	}
+}
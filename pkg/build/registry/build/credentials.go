@@ -0,0 +1,129 @@
+package build
+
+import (
+	"archive/tar"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// CredentialsTokenRedeemer redeems the tokens with which builder containers
+// fetch the credentials of their build.
+type CredentialsTokenRedeemer interface {
+	RedeemBuildCredentialsToken(id, token string) (bool, error)
+}
+
+// CredentialsGetter reads BuildConfigs together with their credentials.
+type CredentialsGetter interface {
+	GetBuildConfig(id string) (*api.BuildConfig, error)
+}
+
+// CredentialsHandler serves the credentials of the BuildConfig of a build to
+// the builder container of the build, which authenticates with the token the
+// build controller passed to the build pod. A token can be used only once.
+type CredentialsHandler struct {
+	registry Registry
+	configs  CredentialsGetter
+	tokens   CredentialsTokenRedeemer
+}
+
+// NewCredentialsHandler creates a new CredentialsHandler.
+func NewCredentialsHandler(registry Registry, configs CredentialsGetter, tokens CredentialsTokenRedeemer) *CredentialsHandler {
+	return &CredentialsHandler{registry, configs, tokens}
+}
+
+// ServeSubresource writes the credentials of the build identified by id as a
+// tar archive. The archive holds one file per secret: ssh-privatekey, username
+// and password for the source repository, and dockercfg for the registry the
+// output image is pushed to. Only the secrets which are set are included.
+func (h *CredentialsHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "GET" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	token := bearerToken(req)
+
+	build, err := h.registry.GetBuild(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if build.Status != api.BuildPending && build.Status != api.BuildRunning {
+		http.Error(w, fmt.Sprintf("Build %s is not running", id), http.StatusForbidden)
+		return
+	}
+	redeemed, err := h.tokens.RedeemBuildCredentialsToken(id, token)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to verify the credentials token of build %s: %v", id, err), http.StatusInternalServerError)
+		return
+	}
+	if !redeemed {
+		http.Error(w, fmt.Sprintf("Invalid credentials token for build %s", id), http.StatusForbidden)
+		return
+	}
+
+	credentials := &api.BuildCredentials{}
+	if build.Config != nil {
+		config, err := h.configs.GetBuildConfig(build.Config.ID)
+		if err != nil && !errors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("Unable to read the credentials of build %s: %v", id, err), http.StatusInternalServerError)
+			return
+		}
+		if config != nil && config.Credentials != nil {
+			credentials = config.Credentials
+		}
+	}
+
+	files := map[string]string{"dockercfg": credentials.DockerConfig}
+	if source := credentials.Source; source != nil {
+		files["ssh-privatekey"] = source.SSHPrivateKey
+		files["username"] = source.Username
+		files["password"] = source.Password
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	if err := writeCredentials(w, files); err != nil {
+		glog.Errorf("Error serving the credentials of build %s: %v", id, err)
+	}
+}
+
+// writeCredentials writes the non-empty files as a tar archive readable only
+// by their owner.
+func writeCredentials(w http.ResponseWriter, files map[string]string) error {
+	archive := tar.NewWriter(w)
+	for _, name := range []string{"ssh-privatekey", "username", "password", "dockercfg"} {
+		content := files[name]
+		if len(content) == 0 {
+			continue
+		}
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// bearerToken returns the token of the bearer authorization of the request,
+// or an empty string when there is none.
+func bearerToken(req *http.Request) string {
+	const prefix = "Bearer "
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(auth[len(prefix):])
+}
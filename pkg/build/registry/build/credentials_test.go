@@ -0,0 +1,109 @@
+package build
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+// singleUseTokens redeems every token of the map once
+type singleUseTokens map[string]string
+
+func (t singleUseTokens) RedeemBuildCredentialsToken(id, token string) (bool, error) {
+	if len(token) == 0 || t[id] != token {
+		return false, nil
+	}
+	delete(t, id)
+	return true, nil
+}
+
+type credentialsConfigs struct {
+	config *api.BuildConfig
+}
+
+func (c *credentialsConfigs) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	return c.config, nil
+}
+
+func getCredentials(handler *CredentialsHandler, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/builds/dataBuild/credentials", nil)
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeSubresource(w, req, "dataBuild")
+	return w
+}
+
+func newCredentialsHandler(status api.BuildStatus) *CredentialsHandler {
+	build := mockBuild()
+	build.Status = status
+	build.Config = &api.BuildConfigReference{ID: "config", Version: 1}
+	configs := &credentialsConfigs{&api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: "config"},
+		Credentials: &api.BuildCredentials{
+			Source:       &api.SourceCredentials{Username: "builder", Password: "s3cret"},
+			DockerConfig: "{}",
+		},
+	}}
+	return NewCredentialsHandler(&test.BuildRegistry{Build: build}, configs, singleUseTokens{"dataBuild": "0123abcd"})
+}
+
+func TestServeCredentials(t *testing.T) {
+	handler := newCredentialsHandler(api.BuildRunning)
+
+	w := getCredentials(handler, "0123abcd")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	files := map[string]string{}
+	archive := tar.NewReader(w.Body)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if header.Mode != 0600 {
+			t.Errorf("Expected %s to be readable only by its owner, got mode %o", header.Name, header.Mode)
+		}
+		content, _ := ioutil.ReadAll(archive)
+		files[header.Name] = string(content)
+	}
+	expected := map[string]string{"username": "builder", "password": "s3cret", "dockercfg": "{}"}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("Expected credentials %v, got %v", expected, files)
+	}
+
+	if w := getCredentials(handler, "0123abcd"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a token to be usable once, got status %d", w.Code)
+	}
+}
+
+func TestServeCredentialsForbidden(t *testing.T) {
+	tests := map[string]struct {
+		status api.BuildStatus
+		token  string
+	}{
+		"no token":       {api.BuildRunning, ""},
+		"invalid token":  {api.BuildRunning, "4567ef"},
+		"finished build": {api.BuildComplete, "0123abcd"},
+	}
+	for desc, test := range tests {
+		w := getCredentials(newCredentialsHandler(test.status), test.token)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d", desc, http.StatusForbidden, w.Code)
+		}
+	}
+}
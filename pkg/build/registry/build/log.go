@@ -0,0 +1,210 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// LogHandler serves the output of the builder container of a build by
+// proxying it from the kubelet on the host that runs the build pod.
+type LogHandler struct {
+	registry    Registry
+	podClient   kubeclient.PodInterface
+	kubeletPort int
+	client      *http.Client
+	pollPeriod  time.Duration
+}
+
+// kubeletTimeout bounds connecting to a kubelet and waiting for the headers of
+// its response. Followed logs are then streamed for as long as the build runs.
+const kubeletTimeout = 30 * time.Second
+
+// NewLogHandler creates a new LogHandler which reaches the kubelets on kubeletPort.
+func NewLogHandler(registry Registry, podClient kubeclient.PodInterface, kubeletPort int) *LogHandler {
+	transport := &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			return net.DialTimeout(network, addr, kubeletTimeout)
+		},
+		ResponseHeaderTimeout: kubeletTimeout,
+	}
+	return &LogHandler{
+		registry:    registry,
+		podClient:   podClient,
+		kubeletPort: kubeletPort,
+		client:      &http.Client{Transport: transport},
+		pollPeriod:  time.Second,
+	}
+}
+
+// ServeSubresource writes the log of the build identified by id. When the
+// follow=true parameter is given, it waits for the build to start and streams
+// the log until the build reaches a terminal status.
+func (h *LogHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "GET" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	follow := req.URL.Query().Get("follow") == "true"
+
+	build, err := h.registry.GetBuild(id)
+	if err == nil && follow && !hasBuildStarted(build) {
+		build, err = h.waitForStart(w, id)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if build == nil {
+		// the client went away before the build started
+		return
+	}
+	if !hasBuildStarted(build) {
+		http.Error(w, fmt.Sprintf("Build %s has not started yet!", build.ID), http.StatusBadRequest)
+		return
+	}
+
+	pod, err := h.podClient.GetPod(build.PodID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to retrieve the pod of build %s: %v", build.ID, err), http.StatusBadGateway)
+		return
+	}
+	location, err := h.logLocation(build, &pod, follow)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	resp, err := h.client.Get(location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to reach the kubelet for build %s: %v", build.ID, err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, fmt.Sprintf("The kubelet returned status %d for build %s!", resp.StatusCode, build.ID), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	out := &flushWriter{w}
+	if !follow {
+		io.Copy(out, resp.Body)
+		return
+	}
+	h.stream(out, resp.Body, build.ID)
+}
+
+// waitForStart polls the build until it has started. It returns a nil build
+// when the client disconnects first.
+func (h *LogHandler) waitForStart(w http.ResponseWriter, id string) (*api.Build, error) {
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+	ticker := time.NewTicker(h.pollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return nil, nil
+		case <-ticker.C:
+			build, err := h.registry.GetBuild(id)
+			if err != nil || hasBuildStarted(build) || buildutil.IsBuildComplete(build) {
+				return build, err
+			}
+		}
+	}
+}
+
+// logLocation returns the kubelet URL serving the builder container output.
+// The builder container is always the first container of the build pod.
+func (h *LogHandler) logLocation(build *api.Build, pod *kubeapi.Pod, follow bool) (string, error) {
+	if len(pod.CurrentState.Host) == 0 {
+		return "", fmt.Errorf("Pod %s for build %s has not been scheduled yet", pod.ID, build.ID)
+	}
+	if len(pod.DesiredState.Manifest.Containers) == 0 {
+		return "", fmt.Errorf("Pod %s for build %s has no containers", pod.ID, build.ID)
+	}
+	location := url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("%s:%d", pod.CurrentState.Host, h.kubeletPort),
+		Path:   fmt.Sprintf("/containerLogs/%s/%s", build.PodID, pod.DesiredState.Manifest.Containers[0].Name),
+	}
+	if follow {
+		location.RawQuery = "follow=true"
+	}
+	return location.String(), nil
+}
+
+// stream copies the followed log to out until the kubelet ends the stream or
+// the build reaches a terminal status, whichever happens first.
+func (h *LogHandler) stream(out io.Writer, log io.ReadCloser, id string) {
+	done := make(chan struct{})
+	go func() {
+		io.Copy(out, log)
+		close(done)
+	}()
+
+	ticker := time.NewTicker(h.pollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			build, err := h.registry.GetBuild(id)
+			if err != nil {
+				glog.Errorf("Error retrieving build %s while following its log: %v", id, err)
+			}
+			if err != nil || buildutil.IsBuildComplete(build) {
+				log.Close()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+// hasBuildStarted returns true once a pod has been created for the build.
+func hasBuildStarted(build *api.Build) bool {
+	return build.Status != api.BuildNew && build.Status != api.BuildQueued && build.Status != api.BuildPending
+}
+
+// writeError writes err with the status matching the error of a registry: a
+// resource which does not exist is not found, an invalid request is a bad
+// request, any other failure is a failure of the server.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.IsInvalid(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// flushWriter flushes every write so that followed logs reach the client
+// as soon as they are produced.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if flusher, ok := fw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,192 @@
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+type podClient struct {
+	kubeclient.Fake
+	host string
+}
+
+func (c *podClient) GetPod(id string) (kubeapi.Pod, error) {
+	return kubeapi.Pod{
+		JSONBase: kubeapi.JSONBase{ID: id},
+		DesiredState: kubeapi.PodState{
+			Manifest: kubeapi.ContainerManifest{
+				Containers: []kubeapi.Container{{Name: "docker-build"}},
+			},
+		},
+		CurrentState: kubeapi.PodState{Host: c.host},
+	}, nil
+}
+
+// newKubeletLogServer starts a stand-in kubelet serving container logs.
+func newKubeletLogServer(t *testing.T, requests *[]string) (*httptest.Server, string, int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*requests = append(*requests, req.URL.String())
+		fmt.Fprint(w, "Step 1 : FROM centos\n")
+	}))
+	serverURL, _ := url.Parse(server.URL)
+	parts := strings.Split(serverURL.Host, ":")
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return server, parts[0], port
+}
+
+func getLog(t *testing.T, handler *LogHandler, query string) (int, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handler.ServeSubresource(w, req, "dataBuild")
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestBuildLog(t *testing.T) {
+	requests := []string{}
+	kubelet, host, port := newKubeletLogServer(t, &requests)
+	defer kubelet.Close()
+	build := mockBuild()
+	build.Status = api.BuildRunning
+	handler := NewLogHandler(&test.BuildRegistry{Build: build}, &podClient{host: host}, port)
+
+	status, body := getLog(t, handler, "")
+
+	if status != http.StatusOK {
+		t.Errorf("Unexpected status %d: %s", status, body)
+	}
+	if e, a := "Step 1 : FROM centos\n", body; e != a {
+		t.Errorf("Expected %q, got %q", e, a)
+	}
+	if len(requests) != 1 || requests[0] != "/containerLogs/-the-pod-id/docker-build" {
+		t.Errorf("Unexpected kubelet requests: %v", requests)
+	}
+}
+
+func TestBuildLogFollow(t *testing.T) {
+	requests := []string{}
+	kubelet, host, port := newKubeletLogServer(t, &requests)
+	defer kubelet.Close()
+	build := mockBuild()
+	build.Status = api.BuildRunning
+	handler := NewLogHandler(&test.BuildRegistry{Build: build}, &podClient{host: host}, port)
+	handler.pollPeriod = time.Millisecond
+
+	status, body := getLog(t, handler, "?follow=true")
+
+	if status != http.StatusOK {
+		t.Errorf("Unexpected status %d: %s", status, body)
+	}
+	if e, a := "Step 1 : FROM centos\n", body; e != a {
+		t.Errorf("Expected %q, got %q", e, a)
+	}
+	if len(requests) != 1 || requests[0] != "/containerLogs/-the-pod-id/docker-build?follow=true" {
+		t.Errorf("Unexpected kubelet requests: %v", requests)
+	}
+}
+
+func TestBuildLogNotStarted(t *testing.T) {
+	build := mockBuild()
+	build.Status = api.BuildNew
+	handler := NewLogHandler(&test.BuildRegistry{Build: build}, &podClient{}, 0)
+
+	status, _ := getLog(t, handler, "")
+
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestBuildLogNotFound(t *testing.T) {
+	registry := &test.BuildRegistry{Err: errors.NewNotFound("build", "dataBuild")}
+	handler := NewLogHandler(registry, &podClient{}, 0)
+
+	status, _ := getLog(t, handler, "?follow=true")
+
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, status)
+	}
+}
+
+func TestBuildLogRegistryError(t *testing.T) {
+	registry := &test.BuildRegistry{Err: fmt.Errorf("etcd unavailable")}
+	handler := NewLogHandler(registry, &podClient{}, 0)
+
+	status, _ := getLog(t, handler, "")
+
+	if status != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+func TestBuildLogKubeletUnreachable(t *testing.T) {
+	kubelet, host, port := newKubeletLogServer(t, &[]string{})
+	kubelet.Close()
+	build := mockBuild()
+	build.Status = api.BuildRunning
+	handler := NewLogHandler(&test.BuildRegistry{Build: build}, &podClient{host: host}, port)
+
+	status, _ := getLog(t, handler, "")
+
+	if status != http.StatusBadGateway {
+		t.Errorf("Expected status %d, got %d", http.StatusBadGateway, status)
+	}
+}
+
+// closeNotifyRecorder is a ResponseRecorder whose client has disconnected.
+type closeNotifyRecorder struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (r *closeNotifyRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func TestBuildLogFollowClientDisconnects(t *testing.T) {
+	build := mockBuild()
+	build.Status = api.BuildNew
+	handler := NewLogHandler(&test.BuildRegistry{Build: build}, &podClient{}, 0)
+	handler.pollPeriod = time.Millisecond
+	w := &closeNotifyRecorder{httptest.NewRecorder(), make(chan bool, 1)}
+	w.closed <- true
+	req, _ := http.NewRequest("GET", "/builds/dataBuild/log?follow=true", nil)
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeSubresource(w, req, "dataBuild")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the handler to stop waiting once the client disconnected")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Unexpected response %q", w.Body.String())
+	}
+}
@@ -2,6 +2,7 @@ package build
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 )
 
@@ -9,6 +10,7 @@ import (
 type Registry interface {
 	ListBuilds(labels labels.Selector) (*api.BuildList, error)
 	GetBuild(id string) (*api.Build, error)
+	WatchBuilds(resourceVersion uint64, filter func(build *api.Build) bool) (watch.Interface, error)
 	CreateBuild(build *api.Build) error
 	UpdateBuild(build *api.Build) error
 	DeleteBuild(id string) error
@@ -10,6 +10,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/api/validation"
 )
@@ -50,6 +51,21 @@ func (storage *Storage) Get(id string) (interface{}, error) {
 	return build, err
 }
 
+// Watch begins watching for new, changed, or deleted Builds.
+func (storage *Storage) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return storage.registry.WatchBuilds(resourceVersion, func(build *api.Build) bool {
+		fields := labels.Set{
+			"ID":     build.ID,
+			"Status": string(build.Status),
+			"PodID":  build.PodID,
+		}
+		if build.Config != nil {
+			fields["Config"] = build.Config.ID
+		}
+		return label.Matches(labels.Set(build.Labels)) && field.Matches(fields)
+	})
+}
+
 // Delete asynchronously deletes the Build specified by its id.
 func (storage *Storage) Delete(id string) (<-chan interface{}, error) {
 	return apiserver.MakeAsync(func() (interface{}, error) {
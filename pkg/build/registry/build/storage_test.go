@@ -341,9 +341,9 @@ func TestBuildStorageValidatesUpdate(t *testing.T) {
 		"empty ID": {
 			JSONBase: kubeapi.JSONBase{ID: ""},
 			Input: api.BuildInput{
-				Type:      api.DockerBuildType,
-				SourceURI: "http://my.build.com/the/build/Dockerfile",
-				ImageTag:  "repository/dataBuild",
+				Type:     api.DockerBuildType,
+				Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+				ImageTag: "repository/dataBuild",
 			},
 		},
 		"empty build input": {
@@ -368,9 +368,9 @@ func mockBuild() *api.Build {
 			ID: "dataBuild",
 		},
 		Input: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://my.build.com/the/build/Dockerfile",
-			ImageTag:  "repository/dataBuild",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			ImageTag: "repository/dataBuild",
 		},
 		Status: api.BuildPending,
 		PodID:  "-the-pod-id",
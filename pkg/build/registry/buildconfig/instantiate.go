@@ -0,0 +1,120 @@
+package buildconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/apiserver"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/api/validation"
+	buildregistry "github.com/openshift/origin/pkg/build/registry/build"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// InstantiateHandler creates builds from BuildConfigs on request, without the
+// webhook secret of the BuildConfig since the caller is authenticated by the API.
+type InstantiateHandler struct {
+	configs Registry
+	builds  buildregistry.Registry
+}
+
+// NewInstantiateHandler creates a new InstantiateHandler.
+func NewInstantiateHandler(configs Registry, builds buildregistry.Registry) *InstantiateHandler {
+	return &InstantiateHandler{configs, builds}
+}
+
+// ServeSubresource creates a build from the DesiredInput of the BuildConfig
+// identified by id and returns it. An api.BuildRequest in the request body
+// overrides the git ref, environment variables or builder image of the build.
+func (h *InstantiateHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	request := &api.BuildRequest{}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	if len(body) > 0 {
+		if err := runtime.Codec.DecodeInto(body, request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid build request: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	build, err := h.instantiate(id, request)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	glog.Infof("Build %s of build config %s was started on request", build.ID, id)
+	apiserver.WriteJSON(http.StatusOK, runtime.Codec, build, w)
+}
+
+// instantiate advances the build counter of the BuildConfig identified by id
+// and creates the build linked to it
+func (h *InstantiateHandler) instantiate(id string, request *api.BuildRequest) (*api.Build, error) {
+	build, err := linkBuild(h.configs, id, func(config *api.BuildConfig, build *api.Build) error {
+		if errs := validation.ValidateBuildRequest(request, &config.DesiredInput); len(errs) > 0 {
+			return errors.NewInvalid("buildRequest", id, errs)
+		}
+		build.Input = applyBuildRequest(config.DesiredInput, request)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := h.builds.CreateBuild(build); err != nil {
+		return nil, err
+	}
+	return build, nil
+}
+
+// linkBuild advances the build counter of the BuildConfig identified by id and
+// returns a new build linked to it, which the caller is responsible for
+// creating. The build receives the DesiredInput of the BuildConfig, prepare may
+// modify it before it is validated.
+func linkBuild(configs Registry, id string, prepare func(config *api.BuildConfig, build *api.Build) error) (*api.Build, error) {
+	return buildutil.LinkBuild(configs, id, func(config *api.BuildConfig, build *api.Build) error {
+		if err := prepare(config, build); err != nil {
+			return err
+		}
+		if errs := validation.ValidateBuild(build); len(errs) > 0 {
+			return errors.NewInvalid("build", build.ID, errs)
+		}
+		return nil
+	})
+}
+
+// applyBuildRequest returns input with the overrides of request applied. The
+// members of input shared with the BuildConfig are not modified.
+func applyBuildRequest(input api.BuildInput, request *api.BuildRequest) api.BuildInput {
+	if len(request.Ref) > 0 && input.Source.Git != nil {
+		git := *input.Source.Git
+		git.Ref = request.Ref
+		input.Source.Git = &git
+	}
+	if len(request.Env) > 0 {
+		input.Env = buildutil.MergeEnv(input.Env, request.Env)
+	}
+	if len(request.BuilderImage) > 0 {
+		switch input.Type {
+		case api.STIBuildType:
+			input.BuilderImage = request.BuilderImage
+		case api.CustomBuildType:
+			if input.Custom != nil {
+				custom := *input.Custom
+				custom.Image = request.BuilderImage
+				input.Custom = &custom
+			}
+		}
+	}
+	return input
+}
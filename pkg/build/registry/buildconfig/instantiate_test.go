@@ -0,0 +1,155 @@
+package buildconfig
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+func postInstantiate(configs Registry, builds *test.BuildRegistry, request *api.BuildRequest) *httptest.ResponseRecorder {
+	body := []byte{}
+	if request != nil {
+		body, _ = runtime.Codec.Encode(request)
+	}
+	req, _ := http.NewRequest("POST", "/buildConfigs/dataBuild/instantiate", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	NewInstantiateHandler(configs, builds).ServeSubresource(w, req, "dataBuild")
+	return w
+}
+
+func TestInstantiateBuildConfig(t *testing.T) {
+	config := mockBuildConfig()
+	config.LastVersion = 4
+	configs := &test.BuildConfigRegistry{BuildConfig: config}
+	builds := &test.BuildRegistry{}
+
+	w := postInstantiate(configs, builds, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := builds.CreatedBuild
+	if build == nil {
+		t.Fatalf("Expected a build to be created")
+	}
+	if build.ID != "dataBuild-5" || build.Config == nil || build.Config.Version != 5 {
+		t.Errorf("Expected the build to be linked to its config, got %#v", build)
+	}
+	if build.Status != api.BuildNew {
+		t.Errorf("Expected a new build, got %s", build.Status)
+	}
+	if build.Input.Source.Git.URI != config.DesiredInput.Source.Git.URI {
+		t.Errorf("Expected the desired input of the config, got %#v", build.Input)
+	}
+	if configs.UpdatedConfig == nil || configs.UpdatedConfig.LastVersion != 5 {
+		t.Errorf("Expected the build counter of the config to be stored, got %#v", configs.UpdatedConfig)
+	}
+}
+
+func TestInstantiateBuildConfigOverrides(t *testing.T) {
+	config := mockBuildConfig()
+	config.DesiredInput.Type = api.STIBuildType
+	config.DesiredInput.BuilderImage = "builder/image"
+	config.DesiredInput.Source.Git.Ref = "master"
+	config.DesiredInput.Env = []kubeapi.EnvVar{{Name: "DEBUG", Value: "false"}, {Name: "MAVEN_MIRROR_URL", Value: "http://mirror"}}
+	configs := &test.BuildConfigRegistry{BuildConfig: config}
+	builds := &test.BuildRegistry{}
+
+	w := postInstantiate(configs, builds, &api.BuildRequest{
+		Ref:          "v1.2",
+		Env:          []kubeapi.EnvVar{{Name: "DEBUG", Value: "true"}},
+		BuilderImage: "builder/image:next",
+	})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	input := builds.CreatedBuild.Input
+	if input.Source.Git.Ref != "v1.2" {
+		t.Errorf("Expected the ref to be overridden, got %s", input.Source.Git.Ref)
+	}
+	if len(input.Env) != 2 || input.Env[0].Value != "true" {
+		t.Errorf("Expected the env to be merged, got %#v", input.Env)
+	}
+	if input.BuilderImage != "builder/image:next" {
+		t.Errorf("Expected the builder image to be overridden, got %s", input.BuilderImage)
+	}
+	desired := configs.UpdatedConfig.DesiredInput
+	if desired.Source.Git.Ref != "master" || desired.Env[0].Value != "false" || desired.BuilderImage != "builder/image" {
+		t.Errorf("Expected the desired input of the config not to be modified, got %#v", desired)
+	}
+}
+
+func TestInstantiateBuildConfigInvalidOverrides(t *testing.T) {
+	configs := &test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}
+	builds := &test.BuildRegistry{}
+
+	w := postInstantiate(configs, builds, &api.BuildRequest{BuilderImage: "builder/image"})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	if builds.CreatedBuild != nil || configs.UpdatedConfig != nil {
+		t.Errorf("Expected nothing to be stored")
+	}
+}
+
+func TestInstantiateBuildConfigNotFound(t *testing.T) {
+	configs := &test.BuildConfigRegistry{Err: kubeerrors.NewNotFound("buildConfig", "dataBuild")}
+	w := postInstantiate(configs, &test.BuildRegistry{}, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestInstantiateBuildConfigMethod(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/buildConfigs/dataBuild/instantiate", nil)
+	w := httptest.NewRecorder()
+	NewInstantiateHandler(&test.BuildConfigRegistry{}, &test.BuildRegistry{}).ServeSubresource(w, req, "dataBuild")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// conflictingConfigRegistry advances the build counter concurrently, rejecting
+// the first update of the config with a conflict
+type conflictingConfigRegistry struct {
+	test.BuildConfigRegistry
+	conflicts int
+}
+
+func (r *conflictingConfigRegistry) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	config := *r.BuildConfig
+	return &config, nil
+}
+
+func (r *conflictingConfigRegistry) UpdateBuildConfig(config *api.BuildConfig) error {
+	if r.conflicts > 0 {
+		r.conflicts--
+		r.BuildConfig.LastVersion++
+		return kubeerrors.NewConflict("buildConfig", config.ID, errors.New("modified"))
+	}
+	return r.BuildConfigRegistry.UpdateBuildConfig(config)
+}
+
+func TestInstantiateBuildConfigConflict(t *testing.T) {
+	configs := &conflictingConfigRegistry{test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}, 1}
+	builds := &test.BuildRegistry{}
+
+	w := postInstantiate(configs, builds, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if id := builds.CreatedBuild.ID; id != "dataBuild-2" {
+		t.Errorf("Expected the build to be numbered after the concurrent build, got %s", id)
+	}
+}
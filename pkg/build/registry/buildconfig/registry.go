@@ -2,6 +2,7 @@ package buildconfig
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 )
 
@@ -9,6 +10,7 @@ import (
 type Registry interface {
 	ListBuildConfigs(labels labels.Selector) (*api.BuildConfigList, error)
 	GetBuildConfig(id string) (*api.BuildConfig, error)
+	WatchBuildConfigs(resourceVersion uint64, filter func(buildConfig *api.BuildConfig) bool) (watch.Interface, error)
 	CreateBuildConfig(buildConfig *api.BuildConfig) error
 	UpdateBuildConfig(buildConfig *api.BuildConfig) error
 	DeleteBuildConfig(id string) error
@@ -10,6 +10,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/build/api/validation"
 )
@@ -37,6 +38,9 @@ func (storage *Storage) List(selector labels.Selector) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	for i := range builds.Items {
+		builds.Items[i].Credentials = nil
+	}
 	return builds, err
 }
 
@@ -46,9 +50,30 @@ func (storage *Storage) Get(id string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	buildConfig.Credentials = nil
 	return buildConfig, err
 }
 
+// Watch begins watching for new, changed, or deleted BuildConfigs.
+func (storage *Storage) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	w, err := storage.registry.WatchBuildConfigs(resourceVersion, func(config *api.BuildConfig) bool {
+		fields := labels.Set{
+			"ID":   config.ID,
+			"Type": string(config.DesiredInput.Type),
+		}
+		return label.Matches(labels.Set(config.Labels)) && field.Matches(fields)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		if config, ok := event.Object.(*api.BuildConfig); ok {
+			event.Object = withoutCredentials(config)
+		}
+		return event, true
+	}), nil
+}
+
 // Delete asynchronously deletes the BuildConfig specified by its id.
 func (storage *Storage) Delete(id string) (<-chan interface{}, error) {
 	return apiserver.MakeAsync(func() (interface{}, error) {
@@ -73,15 +98,21 @@ func (storage *Storage) Create(obj interface{}) (<-chan interface{}, error) {
 		buildConfig.ID = uuid.NewUUID().String()
 	}
 	buildConfig.CreationTimestamp = util.Now()
+	if isEmptyCredentials(buildConfig.Credentials) {
+		buildConfig.Credentials = nil
+	}
 	if errs := validation.ValidateBuildConfig(buildConfig); len(errs) > 0 {
 		return nil, errors.NewInvalid("buildConfig", buildConfig.ID, errs)
 	}
+	if err := storage.validateUpstream(buildConfig); err != nil {
+		return nil, err
+	}
 	return apiserver.MakeAsync(func() (interface{}, error) {
 		err := storage.registry.CreateBuildConfig(buildConfig)
 		if err != nil {
 			return nil, err
 		}
-		return buildConfig, nil
+		return withoutCredentials(buildConfig), nil
 	}), nil
 }
 
@@ -94,11 +125,55 @@ func (storage *Storage) Update(obj interface{}) (<-chan interface{}, error) {
 	if errs := validation.ValidateBuildConfig(buildConfig); len(errs) > 0 {
 		return nil, errors.NewInvalid("buildConfig", buildConfig.ID, errs)
 	}
+	if err := storage.validateUpstream(buildConfig); err != nil {
+		return nil, err
+	}
 	return apiserver.MakeAsync(func() (interface{}, error) {
+		// credentials are never returned to clients, keep the stored ones unless
+		// they are cleared with empty credentials
+		if isEmptyCredentials(buildConfig.Credentials) {
+			buildConfig.Credentials = nil
+		} else if buildConfig.Credentials == nil {
+			existing, err := storage.registry.GetBuildConfig(buildConfig.ID)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				buildConfig.Credentials = existing.Credentials
+			}
+		}
 		err := storage.registry.UpdateBuildConfig(buildConfig)
 		if err != nil {
 			return nil, err
 		}
-		return buildConfig, nil
+		return withoutCredentials(buildConfig), nil
 	}), nil
 }
+
+// validateUpstream rejects a BuildConfig whose upstream relations form a cycle
+// with the relations of the stored BuildConfigs.
+func (storage *Storage) validateUpstream(config *api.BuildConfig) error {
+	if len(config.Upstream) == 0 {
+		return nil
+	}
+	configs, err := storage.registry.ListBuildConfigs(labels.Everything())
+	if err != nil {
+		return err
+	}
+	if errs := validation.ValidateBuildConfigUpstream(config, configs.Items); len(errs) > 0 {
+		return errors.NewInvalid("buildConfig", config.ID, errs)
+	}
+	return nil
+}
+
+// withoutCredentials returns a copy of config which can be returned to clients.
+func withoutCredentials(config *api.BuildConfig) *api.BuildConfig {
+	result := *config
+	result.Credentials = nil
+	return &result
+}
+
+// isEmptyCredentials returns true if credentials are set but hold no credential.
+func isEmptyCredentials(credentials *api.BuildCredentials) bool {
+	return credentials != nil && credentials.Source == nil && len(credentials.DockerConfig) == 0
+}
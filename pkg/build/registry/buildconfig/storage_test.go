@@ -45,6 +45,19 @@ func TestGetConfig(t *testing.T) {
 	}
 }
 
+func TestGetConfigHidesCredentials(t *testing.T) {
+	config := mockBuildConfig()
+	config.Credentials = &api.BuildCredentials{DockerConfig: "{}"}
+	storage := Storage{registry: &test.BuildConfigRegistry{BuildConfig: config}}
+	configObj, err := storage.Get("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error returned: %v", err)
+	}
+	if credentials := configObj.(*api.BuildConfig).Credentials; credentials != nil {
+		t.Errorf("Expected credentials to be hidden, got %#v", credentials)
+	}
+}
+
 func TestGetConfigError(t *testing.T) {
 	mockRegistry := test.BuildConfigRegistry{Err: fmt.Errorf("get error")}
 	storage := Storage{registry: &mockRegistry}
@@ -172,6 +185,27 @@ func TestListConfigs(t *testing.T) {
 	}
 }
 
+func TestListConfigsHidesCredentials(t *testing.T) {
+	mockRegistry := test.BuildConfigRegistry{
+		BuildConfigs: &api.BuildConfigList{
+			Items: []api.BuildConfig{
+				{
+					JSONBase:    kubeapi.JSONBase{ID: "foo"},
+					Credentials: &api.BuildCredentials{DockerConfig: "{}"},
+				},
+			},
+		},
+	}
+	storage := Storage{registry: &mockRegistry}
+	configsObj, err := storage.List(labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if credentials := configsObj.(*api.BuildConfigList).Items[0].Credentials; credentials != nil {
+		t.Errorf("Expected credentials to be hidden, got %#v", credentials)
+	}
+}
+
 func TestBuildConfigDecode(t *testing.T) {
 	mockRegistry := test.BuildConfigRegistry{}
 	storage := Storage{
@@ -262,9 +296,9 @@ func mockBuildConfig() *api.BuildConfig {
 			ID: "dataBuild",
 		},
 		DesiredInput: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://my.build.com/the/buildConfig/Dockerfile",
-			ImageTag:  "repository/dataBuild",
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/buildConfig/Dockerfile"}},
+			ImageTag: "repository/dataBuild",
 		},
 		Labels: map[string]string{
 			"name": "dataBuild",
@@ -299,6 +333,55 @@ func TestUpdateBuildConfig(t *testing.T) {
 	}
 }
 
+func TestUpdateBuildConfigKeepsCredentials(t *testing.T) {
+	stored := mockBuildConfig()
+	stored.Credentials = &api.BuildCredentials{
+		Source: &api.SourceCredentials{Username: "user", Password: "secret"},
+	}
+	mockRegistry := test.BuildConfigRegistry{BuildConfig: stored}
+	storage := Storage{&mockRegistry}
+	channel, err := storage.Update(mockBuildConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case result := <-channel:
+		obj, ok := result.(*api.BuildConfig)
+		if !ok {
+			t.Fatalf("Unexpected result type: %v", result)
+		}
+		if obj.Credentials != nil {
+			t.Errorf("Expected credentials to be hidden, got %#v", obj.Credentials)
+		}
+	case <-time.After(time.Millisecond * 100):
+		t.Fatal("Unexpected timeout from async channel")
+	}
+	if mockRegistry.UpdatedConfig == nil || mockRegistry.UpdatedConfig.Credentials != stored.Credentials {
+		t.Errorf("Expected the stored credentials to be kept, got %#v", mockRegistry.UpdatedConfig)
+	}
+}
+
+func TestUpdateBuildConfigClearsCredentials(t *testing.T) {
+	stored := mockBuildConfig()
+	stored.Credentials = &api.BuildCredentials{DockerConfig: "{}"}
+	mockRegistry := test.BuildConfigRegistry{BuildConfig: stored}
+	storage := Storage{&mockRegistry}
+	buildConfig := mockBuildConfig()
+	buildConfig.Credentials = &api.BuildCredentials{}
+	channel, err := storage.Update(buildConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-channel:
+	case <-time.After(time.Millisecond * 100):
+		t.Fatal("Unexpected timeout from async channel")
+	}
+	if mockRegistry.UpdatedConfig == nil || mockRegistry.UpdatedConfig.Credentials != nil {
+		t.Errorf("Expected the stored credentials to be removed, got %#v", mockRegistry.UpdatedConfig)
+	}
+}
+
 func TestUpdateBuildConfigError(t *testing.T) {
 	mockRegistry := test.BuildConfigRegistry{Err: fmt.Errorf("Update error")}
 	storage := Storage{&mockRegistry}
@@ -326,10 +409,10 @@ func TestBuildConfigStorageValidatesCreate(t *testing.T) {
 	mockRegistry := test.BuildConfigRegistry{}
 	storage := Storage{&mockRegistry}
 	failureCases := map[string]api.BuildConfig{
-		"blank sourceURI": {
+		"blank source uri": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI:    "",
+				Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: ""}},
 				ImageTag:     "data/image",
 				Type:         api.STIBuildType,
 				BuilderImage: "builder/image",
@@ -338,15 +421,15 @@ func TestBuildConfigStorageValidatesCreate(t *testing.T) {
 		"blank ImageTag": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI: "http://github.com/test/source",
-				ImageTag:  "",
-				Type:      api.DockerBuildType,
+				Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
+				ImageTag: "",
+				Type:     api.DockerBuildType,
 			},
 		},
 		"blank BuilderImage": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI:    "http://github.com/test/source",
+				Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
 				ImageTag:     "data/image",
 				Type:         api.STIBuildType,
 				BuilderImage: "",
@@ -371,15 +454,15 @@ func TestBuildStorageValidatesUpdate(t *testing.T) {
 		"empty ID": {
 			JSONBase: kubeapi.JSONBase{ID: ""},
 			DesiredInput: api.BuildInput{
-				SourceURI: "http://github.com/test/source",
-				ImageTag:  "data/image",
-				Type:      api.DockerBuildType,
+				Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
+				ImageTag: "data/image",
+				Type:     api.DockerBuildType,
 			},
 		},
-		"blank sourceURI": {
+		"blank source uri": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI:    "",
+				Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: ""}},
 				ImageTag:     "data/image",
 				Type:         api.STIBuildType,
 				BuilderImage: "builder/image",
@@ -388,15 +471,15 @@ func TestBuildStorageValidatesUpdate(t *testing.T) {
 		"blank ImageTag": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI: "http://github.com/test/source",
-				ImageTag:  "",
-				Type:      api.DockerBuildType,
+				Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
+				ImageTag: "",
+				Type:     api.DockerBuildType,
 			},
 		},
 		"blank BuilderImage on STIBuildType": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI:    "http://github.com/test/source",
+				Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
 				ImageTag:     "data/image",
 				Type:         api.STIBuildType,
 				BuilderImage: "",
@@ -405,7 +488,7 @@ func TestBuildStorageValidatesUpdate(t *testing.T) {
 		"non-blank BuilderImage on DockerBuildType": {
 			JSONBase: kubeapi.JSONBase{ID: "abc"},
 			DesiredInput: api.BuildInput{
-				SourceURI:    "http://github.com/test/source",
+				Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://github.com/test/source"}},
 				ImageTag:     "data/image",
 				Type:         api.DockerBuildType,
 				BuilderImage: "builder/image",
@@ -422,3 +505,27 @@ func TestBuildStorageValidatesUpdate(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildConfigStorageRejectsUpstreamCycles(t *testing.T) {
+	library := mockBuildConfig()
+	library.ID = "library"
+	library.Upstream = []string{"dataBuild"}
+	mockRegistry := test.BuildConfigRegistry{
+		BuildConfigs: &api.BuildConfigList{Items: []api.BuildConfig{*library}},
+	}
+	storage := Storage{&mockRegistry}
+
+	buildConfig := mockBuildConfig()
+	buildConfig.Upstream = []string{"library"}
+	if _, err := storage.Create(buildConfig); !errors.IsInvalid(err) {
+		t.Errorf("Expected the cycle to be rejected on create, got %v", err)
+	}
+	if _, err := storage.Update(buildConfig); !errors.IsInvalid(err) {
+		t.Errorf("Expected the cycle to be rejected on update, got %v", err)
+	}
+
+	buildConfig.Upstream = []string{"base"}
+	if _, err := storage.Update(buildConfig); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
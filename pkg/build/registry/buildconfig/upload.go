@@ -0,0 +1,91 @@
+package buildconfig
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/apiserver"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/archive"
+	buildregistry "github.com/openshift/origin/pkg/build/registry/build"
+)
+
+// UploadHandler creates builds of archives uploaded to a BuildConfig. The
+// archive is kept in a store on the master until the build is complete, and
+// the builder pod downloads it from the archive operation of the build.
+type UploadHandler struct {
+	configs Registry
+	builds  buildregistry.Registry
+	store   *archive.Store
+	baseURL string
+}
+
+// NewUploadHandler creates a new UploadHandler. baseURL is the URL of the
+// OpenShift API as seen from the builder pods, uploads are refused when it is
+// empty.
+func NewUploadHandler(configs Registry, builds buildregistry.Registry, store *archive.Store, baseURL string) *UploadHandler {
+	return &UploadHandler{configs, builds, store, baseURL}
+}
+
+// ServeSubresource creates a build from the DesiredInput of the BuildConfig
+// identified by id whose source is the tar or zip archive in the request body,
+// and returns it. The archive replaces the source of the BuildConfig: the
+// Docker strategy uses it as the build context and the STI strategy as the
+// source directory.
+func (h *UploadHandler) ServeSubresource(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	if len(h.baseURL) == 0 {
+		http.Error(w, "Builds from uploaded archives require the URL of the OpenShift API reachable from build pods, set OPENSHIFT_MASTER_URL", http.StatusServiceUnavailable)
+		return
+	}
+	if req.ContentLength > h.store.MaxSize() {
+		http.Error(w, fmt.Sprintf("The archive exceeds the maximum size of %d bytes", h.store.MaxSize()), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// the archive is received before the build counter is advanced, so that a
+	// failed upload does not leave a gap in the numbers of the builds
+	upload, size, err := h.store.Stage(req.Body)
+	if err == archive.ErrTooLarge {
+		http.Error(w, fmt.Sprintf("The archive exceeds the maximum size of %d bytes", h.store.MaxSize()), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+
+	build, err := linkBuild(h.configs, id, func(config *api.BuildConfig, build *api.Build) error {
+		build.Input.Source = api.BuildSource{
+			Type: api.ArchiveBuildSourceType,
+			Archive: &api.ArchiveBuildSource{
+				URL: fmt.Sprintf("%s/builds/%s/archive", h.baseURL, build.ID),
+			},
+		}
+		return nil
+	})
+	if err == nil {
+		err = h.store.Commit(upload, build.ID)
+	}
+	if err != nil {
+		if err := h.store.Discard(upload); err != nil {
+			glog.Errorf("Error removing an archive uploaded to build config %s: %v", id, err)
+		}
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	if err := h.builds.CreateBuild(build); err != nil {
+		if err := h.store.Remove(build.ID); err != nil {
+			glog.Errorf("Error removing the archive of build %s: %v", build.ID, err)
+		}
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	glog.Infof("Build %s of build config %s was started with an uploaded archive of %d bytes", build.ID, id, size)
+	apiserver.WriteJSON(http.StatusOK, runtime.Codec, build, w)
+}
@@ -0,0 +1,143 @@
+package buildconfig
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/archive"
+	"github.com/openshift/origin/pkg/build/registry/test"
+)
+
+func postUpload(configs Registry, builds *test.BuildRegistry, store *archive.Store, body io.Reader) *httptest.ResponseRecorder {
+	return postUploadTo("http://master:8080/osapi/v1beta1", configs, builds, store, body)
+}
+
+func postUploadTo(baseURL string, configs Registry, builds *test.BuildRegistry, store *archive.Store, body io.Reader) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/buildConfigs/dataBuild/upload", body)
+	w := httptest.NewRecorder()
+	NewUploadHandler(configs, builds, store, baseURL).ServeSubresource(w, req, "dataBuild")
+	return w
+}
+
+func TestUploadBuildConfigArchive(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	configs := &test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}
+	builds := &test.BuildRegistry{}
+
+	w := postUpload(configs, builds, store, strings.NewReader("archive content"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	build := builds.CreatedBuild
+	if build == nil || build.ID != "dataBuild-1" {
+		t.Fatalf("Expected a build linked to the config, got %#v", build)
+	}
+	source := build.Input.Source
+	if source.Type != api.ArchiveBuildSourceType || source.Git != nil {
+		t.Errorf("Expected an archive source, got %#v", source)
+	}
+	if e, a := "http://master:8080/osapi/v1beta1/builds/dataBuild-1/archive", source.Archive.URL; e != a {
+		t.Errorf("Expected archive URL %s, got %s", e, a)
+	}
+	if configs.UpdatedConfig.DesiredInput.Source.Type != api.GitBuildSourceType {
+		t.Errorf("Expected the source of the config not to be modified")
+	}
+	file, err := store.Open("dataBuild-1")
+	if err != nil {
+		t.Fatalf("Expected the archive to be stored, got %v", err)
+	}
+	defer file.Close()
+	if content, _ := ioutil.ReadAll(file); string(content) != "archive content" {
+		t.Errorf("Unexpected archive content %q", content)
+	}
+}
+
+func TestUploadBuildConfigArchiveTooLarge(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 8)
+	defer cleanup()
+
+	// the length of a streamed request is unknown until the archive is read
+	for _, body := range []io.Reader{strings.NewReader("archive content"), io.MultiReader(strings.NewReader("archive content"))} {
+		configs := &test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}
+		builds := &test.BuildRegistry{}
+		w := postUpload(configs, builds, store, body)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		}
+		if builds.CreatedBuild != nil {
+			t.Errorf("Expected no build to be created")
+		}
+		if configs.UpdatedConfig != nil {
+			t.Errorf("Expected the build counter not to be advanced, got %#v", configs.UpdatedConfig)
+		}
+	}
+}
+
+func TestUploadBuildConfigArchiveCreateFails(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	builds := &test.BuildRegistry{Err: errors.New("etcd unavailable")}
+
+	w := postUpload(&test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}, builds, store, strings.NewReader("archive"))
+
+	if w.Code == http.StatusOK {
+		t.Fatalf("Expected the upload to fail")
+	}
+	if _, err := store.Open("dataBuild-1"); !os.IsNotExist(err) {
+		t.Errorf("Expected the archive to be removed, got %v", err)
+	}
+}
+
+func TestUploadBuildConfigArchiveConfigNotFound(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	configs := &test.BuildConfigRegistry{Err: kubeerrors.NewNotFound("buildConfig", "dataBuild")}
+
+	w := postUpload(configs, &test.BuildRegistry{}, store, strings.NewReader("archive"))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if entries, _ := store.List(); len(entries) != 0 {
+		t.Errorf("Expected the uploaded archive to be discarded, got %#v", entries)
+	}
+}
+
+func TestUploadBuildConfigArchiveNoBaseURL(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	builds := &test.BuildRegistry{}
+
+	w := postUploadTo("", &test.BuildConfigRegistry{BuildConfig: mockBuildConfig()}, builds, store, strings.NewReader("archive"))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if builds.CreatedBuild != nil {
+		t.Errorf("Expected no build to be created, got %#v", builds.CreatedBuild)
+	}
+	if entries, _ := store.List(); len(entries) != 0 {
+		t.Errorf("Expected no archive to be stored, got %#v", entries)
+	}
+}
+
+func TestUploadBuildConfigArchiveMethod(t *testing.T) {
+	store, cleanup := archive.NewTestStore(t, 64)
+	defer cleanup()
+	req, _ := http.NewRequest("GET", "/buildConfigs/dataBuild/upload", nil)
+	w := httptest.NewRecorder()
+	NewUploadHandler(&test.BuildConfigRegistry{}, &test.BuildRegistry{}, store, "").ServeSubresource(w, req, "dataBuild")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 )
 
@@ -10,6 +11,8 @@ type BuildRegistry struct {
 	Builds         *api.BuildList
 	Build          *api.Build
 	DeletedBuildId string
+	UpdatedBuild   *api.Build
+	CreatedBuild   *api.Build
 }
 
 func (r *BuildRegistry) ListBuilds(labels labels.Selector) (*api.BuildList, error) {
@@ -20,11 +23,17 @@ func (r *BuildRegistry) GetBuild(id string) (*api.Build, error) {
 	return r.Build, r.Err
 }
 
+func (r *BuildRegistry) WatchBuilds(resourceVersion uint64, filter func(build *api.Build) bool) (watch.Interface, error) {
+	return nil, r.Err
+}
+
 func (r *BuildRegistry) CreateBuild(build *api.Build) error {
+	r.CreatedBuild = build
 	return r.Err
 }
 
 func (r *BuildRegistry) UpdateBuild(build *api.Build) error {
+	r.UpdatedBuild = build
 	return r.Err
 }
 
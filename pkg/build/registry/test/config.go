@@ -2,6 +2,7 @@ package test
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/openshift/origin/pkg/build/api"
 )
 
@@ -10,6 +11,7 @@ type BuildConfigRegistry struct {
 	BuildConfigs    *api.BuildConfigList
 	BuildConfig     *api.BuildConfig
 	DeletedConfigId string
+	UpdatedConfig   *api.BuildConfig
 }
 
 func (r *BuildConfigRegistry) ListBuildConfigs(labels labels.Selector) (*api.BuildConfigList, error) {
@@ -20,11 +22,16 @@ func (r *BuildConfigRegistry) GetBuildConfig(id string) (*api.BuildConfig, error
 	return r.BuildConfig, r.Err
 }
 
+func (r *BuildConfigRegistry) WatchBuildConfigs(resourceVersion uint64, filter func(config *api.BuildConfig) bool) (watch.Interface, error) {
+	return nil, r.Err
+}
+
 func (r *BuildConfigRegistry) CreateBuildConfig(config *api.BuildConfig) error {
 	return r.Err
 }
 
 func (r *BuildConfigRegistry) UpdateBuildConfig(config *api.BuildConfig) error {
+	r.UpdatedConfig = config
 	return r.Err
 }
 
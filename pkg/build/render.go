@@ -0,0 +1,132 @@
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/openshift/origin/pkg/apiserver"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/api/validation"
+	"github.com/openshift/origin/pkg/build/strategy"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// RedactedValue replaces the credentials token of a build in rendered build pods.
+const RedactedValue = "REDACTED"
+
+// PodRenderer renders the pod the build controller would create for a build
+// without creating it or storing anything, so that the behavior of the build
+// strategies can be inspected. No credentials token is issued for the build.
+type PodRenderer struct {
+	buildStrategies map[api.BuildType]BuildJobStrategy
+	dockerRegistry  string
+	configs         BuildConfigGetter
+	credentials     *CredentialsIssuer
+}
+
+// NewPodRenderer creates a new PodRenderer, whose strategies, Docker registry
+// and credentials issuer must match the ones of the build controller.
+func NewPodRenderer(buildStrategies map[api.BuildType]BuildJobStrategy, dockerRegistry string, configs BuildConfigGetter, credentials *CredentialsIssuer) *PodRenderer {
+	return &PodRenderer{
+		buildStrategies: buildStrategies,
+		dockerRegistry:  dockerRegistry,
+		configs:         configs,
+		credentials:     credentials,
+	}
+}
+
+// ServeHTTP renders the pod of the api.Build or api.BuildConfig in the request
+// body. The object is validated first, it does not need to exist.
+func (r *PodRenderer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	obj, err := runtime.Codec.Decode(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid build or build config: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var pod *kubeapi.Pod
+	switch obj := obj.(type) {
+	case *api.Build:
+		if errs := validation.ValidateBuild(obj); len(errs) > 0 {
+			err = errors.NewInvalid("build", obj.ID, errs)
+			break
+		}
+		pod, err = r.RenderBuildPod(obj)
+	case *api.BuildConfig:
+		if errs := validation.ValidateBuildConfig(obj); len(errs) > 0 {
+			err = errors.NewInvalid("buildConfig", obj.ID, errs)
+			break
+		}
+		pod, err = r.RenderBuildConfigPod(obj)
+	default:
+		http.Error(w, fmt.Sprintf("Expected a build or build config, got %T", obj), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		apiserver.ErrorJSON(err, runtime.Codec, w)
+		return
+	}
+	apiserver.WriteJSON(http.StatusOK, runtime.Codec, pod, w)
+}
+
+// RenderBuildPod returns the pod of build, with the credentials of the
+// BuildConfig it was created from.
+func (r *PodRenderer) RenderBuildPod(build *api.Build) (*kubeapi.Pod, error) {
+	credentials, err := configCredentials(r.configs, build)
+	if err != nil {
+		return nil, err
+	}
+	return r.render(build, credentials)
+}
+
+// RenderBuildConfigPod returns the pod of the next build of config, which is
+// not modified. The credentials of config are used if set, the stored
+// credentials of the BuildConfig otherwise.
+func (r *PodRenderer) RenderBuildConfigPod(config *api.BuildConfig) (*kubeapi.Pod, error) {
+	next := *config
+	build := &api.Build{
+		Input:  config.DesiredInput,
+		Status: api.BuildNew,
+	}
+	buildutil.LinkBuildToConfig(build, &next)
+
+	credentials := config.Credentials
+	if credentials == nil {
+		var err error
+		if credentials, err = configCredentials(r.configs, build); err != nil {
+			return nil, err
+		}
+	}
+	return r.render(build, credentials)
+}
+
+// render creates the pod of build with the strategy of the build controller.
+// A build which has no pod yet receives the pod id the controller assigns.
+func (r *PodRenderer) render(build *api.Build, credentials *api.BuildCredentials) (*kubeapi.Pod, error) {
+	buildStrategy, ok := r.buildStrategies[build.Input.Type]
+	if !ok {
+		return nil, errors.NewInvalid("build", build.ID, errors.ErrorList{errors.NewFieldNotSupported("input.type", build.Input.Type)})
+	}
+	rendered := *build
+	if len(rendered.PodID) == 0 {
+		rendered.PodID = buildPodID(&rendered)
+	}
+	var source *strategy.CredentialsSource
+	if credentials != nil && r.credentials != nil {
+		source = r.credentials.Redacted(&rendered)
+	}
+	return buildStrategy.CreateBuildPod(&rendered, r.dockerRegistry, source)
+}
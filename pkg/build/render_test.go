@@ -0,0 +1,168 @@
+package build
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
+)
+
+// renderStrategy records the build and credentials it renders a pod for
+type renderStrategy struct {
+	build       *api.Build
+	credentials *strategy.CredentialsSource
+}
+
+func (s *renderStrategy) CreateBuildPod(build *api.Build, dockerRegistry string, credentials *strategy.CredentialsSource) (*kubeapi.Pod, error) {
+	s.build = build
+	s.credentials = credentials
+	return &kubeapi.Pod{JSONBase: kubeapi.JSONBase{ID: build.PodID}}, nil
+}
+
+func mockRenderConfig() *api.BuildConfig {
+	return &api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: "config"},
+		DesiredInput: api.BuildInput{
+			Type:     api.DockerBuildType,
+			Source:   api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			ImageTag: "repository/config",
+		},
+		Credentials: &api.BuildCredentials{
+			Source:       &api.SourceCredentials{Username: "builder", Password: "s3cret"},
+			DockerConfig: `{"auth":"s3cret"}`,
+		},
+		LastVersion: 6,
+	}
+}
+
+func TestRenderBuildPodRedactsCredentials(t *testing.T) {
+	renderer := &renderStrategy{}
+	config := mockRenderConfig()
+	tokens := credentialsTokens{}
+	r := NewPodRenderer(map[api.BuildType]BuildJobStrategy{api.DockerBuildType: renderer}, "registry:5000", &credentialsConfigGetter{config: config}, NewCredentialsIssuer(tokens, "http://master:8080/osapi/v1beta1"))
+	build := &api.Build{
+		JSONBase: kubeapi.JSONBase{ID: "config-6"},
+		Input:    config.DesiredInput,
+		Config:   &api.BuildConfigReference{ID: "config", Version: 6},
+	}
+
+	pod, err := r.RenderBuildPod(build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.ID != "build-docker-config-6" {
+		t.Errorf("Expected the pod id assigned by the controller, got %s", pod.ID)
+	}
+	if len(build.PodID) != 0 {
+		t.Errorf("Expected the build not to be modified, got pod id %s", build.PodID)
+	}
+	credentials := renderer.credentials
+	if credentials == nil || credentials.URL != "http://master:8080/osapi/v1beta1/builds/config-6/credentials" {
+		t.Fatalf("Expected the location of the credentials to be passed, got %#v", credentials)
+	}
+	if credentials.Token != RedactedValue || len(tokens) != 0 {
+		t.Errorf("Expected no credentials token to be issued, got %#v and %v", credentials, tokens)
+	}
+}
+
+func TestRenderBuildConfigPod(t *testing.T) {
+	renderer := &renderStrategy{}
+	r := NewPodRenderer(map[api.BuildType]BuildJobStrategy{api.DockerBuildType: renderer}, "", nil, NewCredentialsIssuer(credentialsTokens{}, ""))
+	config := mockRenderConfig()
+
+	pod, err := r.RenderBuildConfigPod(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.ID != "build-docker-config-7" {
+		t.Errorf("Expected the pod of the next build of the config, got %s", pod.ID)
+	}
+	if renderer.build.Config == nil || renderer.build.Config.Version != 7 {
+		t.Errorf("Expected the build to be linked to the config, got %#v", renderer.build.Config)
+	}
+	if config.LastVersion != 6 {
+		t.Errorf("Expected the config not to be modified, got version %d", config.LastVersion)
+	}
+	if renderer.credentials == nil || renderer.credentials.Token != RedactedValue {
+		t.Errorf("Expected the credentials of the config to be passed redacted, got %#v", renderer.credentials)
+	}
+}
+
+func TestRenderBuildPodUnknownStrategy(t *testing.T) {
+	r := NewPodRenderer(map[api.BuildType]BuildJobStrategy{}, "", nil, nil)
+	if _, err := r.RenderBuildConfigPod(mockRenderConfig()); err == nil {
+		t.Errorf("Expected an error for a build type without strategy")
+	}
+}
+
+func postRender(r *PodRenderer, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/osapi/v1beta1/renderBuildPod", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestServeRenderBuildPod(t *testing.T) {
+	strategies := map[api.BuildType]BuildJobStrategy{
+		api.DockerBuildType: strategy.NewDockerBuildStrategy("openshift/docker-builder", true),
+	}
+	r := NewPodRenderer(strategies, "registry:5000", nil, NewCredentialsIssuer(credentialsTokens{}, "http://master:8080/osapi/v1beta1"))
+	body, _ := runtime.Codec.Encode(mockRenderConfig())
+
+	w := postRender(r, body)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("Expected the credentials to be redacted, got %s", w.Body.String())
+	}
+	pod := &kubeapi.Pod{}
+	if err := runtime.Codec.DecodeInto(w.Body.Bytes(), pod); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	manifest := pod.DesiredState.Manifest
+	if len(manifest.Containers) != 1 || len(manifest.Volumes) != 2 {
+		t.Fatalf("Expected a builder container with the build result and the Docker socket, got %#v", manifest)
+	}
+	env := map[string]string{}
+	for _, v := range manifest.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	if env["CREDENTIALS_TOKEN"] != RedactedValue || env["CREDENTIALS_URL"] != "http://master:8080/osapi/v1beta1/builds/config-7/credentials" {
+		t.Errorf("Expected the location of the credentials to be passed with a redacted token, got %#v", env)
+	}
+}
+
+func TestServeRenderBuildPodInvalid(t *testing.T) {
+	r := NewPodRenderer(map[api.BuildType]BuildJobStrategy{api.DockerBuildType: &renderStrategy{}}, "", nil, nil)
+
+	config := mockRenderConfig()
+	config.DesiredInput.ImageTag = ""
+	body, _ := runtime.Codec.Encode(config)
+	if w := postRender(r, body); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for an invalid config, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+
+	body, _ = runtime.Codec.Encode(&api.BuildRequest{})
+	if w := postRender(r, body); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an object which is not a build, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := postRender(r, []byte("{")); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an undecodable body, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req, _ := http.NewRequest("GET", "/osapi/v1beta1/renderBuildPod", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,141 @@
+package build
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/fsouza/go-dockerclient"
+	"github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/strategy"
+)
+
+// BuildResultMarker prefixes the line of the build result file which reports
+// the image produced by the build. It is followed by the output of docker
+// inspect for the image, on a single line.
+const BuildResultMarker = "OPENSHIFT_BUILD_RESULT "
+
+// BuildRevisionMarker prefixes the line of the build result file which reports
+// the revision of the build. It is followed by the JSON encoding of an
+// api.BuildRevision, on a single line.
+const BuildRevisionMarker = "OPENSHIFT_BUILD_REVISION "
+
+// BuildResult is what the builder container of a build pod reported.
+type BuildResult struct {
+	// Image is the image produced by the build, nil if the builder reported none
+	Image *docker.Image
+	// Revision identifies the inputs the builder resolved, nil if it reported none
+	Revision *api.BuildRevision
+}
+
+// BuildResultReader obtains the result reported by the builder container of a
+// build pod which has terminated.
+type BuildResultReader interface {
+	ReadBuildResult(pod *kubeapi.Pod) (*BuildResult, error)
+}
+
+// KubeletResultReader reads the build result from the result file written by
+// the builder container, which it fetches from the kubelet on the host that ran
+// the pod. The kubelet serves the result directory of the pod below /logs/.
+type KubeletResultReader struct {
+	kubeletPort int
+	client      *http.Client
+}
+
+// NewKubeletResultReader creates a new KubeletResultReader which reaches the
+// kubelets on kubeletPort. Reading a result fails after timeout, so that an
+// unresponsive kubelet does not stall the build controller.
+func NewKubeletResultReader(kubeletPort int, timeout time.Duration) *KubeletResultReader {
+	// every request uses its own connection, whose deadline bounds the request
+	transport := &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			conn, err := net.DialTimeout(network, addr, timeout)
+			if err != nil {
+				return nil, err
+			}
+			return conn, conn.SetDeadline(time.Now().Add(timeout))
+		},
+		DisableKeepAlives: true,
+	}
+	return &KubeletResultReader{
+		kubeletPort: kubeletPort,
+		client:      &http.Client{Transport: transport},
+	}
+}
+
+// ReadBuildResult returns the image and revision reported last by the builder
+// container. It fails if the builder reported neither.
+func (r *KubeletResultReader) ReadBuildResult(pod *kubeapi.Pod) (*BuildResult, error) {
+	location := url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("%s:%d", pod.CurrentState.Host, r.kubeletPort),
+		Path:   path.Join("/logs", strings.TrimPrefix(strategy.BuildResultHostDir, "/var/log"), pod.ID, strategy.BuildResultFile),
+	}
+	resp, err := r.client.Get(location.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.New("the builder did not report a result")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to retrieve the result of pod %s: %s", pod.ID, resp.Status)
+	}
+
+	image, revision := "", ""
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, BuildResultMarker) {
+			image = strings.TrimSpace(strings.TrimPrefix(line, BuildResultMarker))
+		}
+		if strings.HasPrefix(line, BuildRevisionMarker) {
+			revision = strings.TrimSpace(strings.TrimPrefix(line, BuildRevisionMarker))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(image) == 0 && len(revision) == 0 {
+		return nil, errors.New("the builder did not report a result")
+	}
+
+	result := &BuildResult{}
+	if len(image) > 0 {
+		if result.Image, err = parseBuildResult(image); err != nil {
+			return nil, err
+		}
+	}
+	if len(revision) > 0 {
+		result.Revision = &api.BuildRevision{}
+		if err := json.Unmarshal([]byte(revision), result.Revision); err != nil {
+			return nil, fmt.Errorf("invalid build revision: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// parseBuildResult decodes the output of docker inspect for a single image.
+func parseBuildResult(result string) (*docker.Image, error) {
+	images := []docker.Image{}
+	if err := json.Unmarshal([]byte(result), &images); err != nil {
+		return nil, fmt.Errorf("invalid build result: %v", err)
+	}
+	if len(images) != 1 || len(images[0].ID) == 0 {
+		return nil, fmt.Errorf("expected the build result to describe one image, got %d", len(images))
+	}
+	return &images[0], nil
+}
@@ -0,0 +1,159 @@
+package build
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet"
+	"github.com/google/cadvisor/info"
+)
+
+// logsHost is a kubelet host which serves logDir the way the kubelet serves
+// the /var/log directory of the node.
+type logsHost struct {
+	logDir string
+}
+
+func (h *logsHost) GetContainerInfo(podFullName, containerName string, req *info.ContainerInfoRequest) (*info.ContainerInfo, error) {
+	return nil, nil
+}
+func (h *logsHost) GetRootInfo(req *info.ContainerInfoRequest) (*info.ContainerInfo, error) {
+	return nil, nil
+}
+func (h *logsHost) GetMachineInfo() (*info.MachineInfo, error) { return nil, nil }
+func (h *logsHost) GetPodInfo(name string) (kubeapi.PodInfo, error) {
+	return nil, nil
+}
+func (h *logsHost) RunInContainer(name, container string, cmd []string) ([]byte, error) {
+	return nil, nil
+}
+func (h *logsHost) ServeLogs(w http.ResponseWriter, req *http.Request) {
+	http.StripPrefix("/logs/", http.FileServer(http.Dir(h.logDir))).ServeHTTP(w, req)
+}
+
+// newKubeletResultServer starts a kubelet server on whose node the pod
+// build-docker-dataBuild reported the given result. An empty result means
+// the builder reported none.
+func newKubeletResultServer(t *testing.T, result string) (string, int, func()) {
+	logDir, err := ioutil.TempDir("", "kubelet-logs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result) > 0 {
+		resultDir := path.Join(logDir, "openshift-builds", "build-docker-dataBuild")
+		if err := os.MkdirAll(resultDir, 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(resultDir, "result"), []byte(result), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	handler := kubelet.NewServer(&logsHost{logDir}, make(chan interface{}, 1))
+	server := httptest.NewServer(&handler)
+	serverURL, _ := url.Parse(server.URL)
+	parts := strings.Split(serverURL.Host, ":")
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return parts[0], port, func() {
+		server.Close()
+		os.RemoveAll(logDir)
+	}
+}
+
+func mockResultPod(host string) *kubeapi.Pod {
+	return &kubeapi.Pod{
+		JSONBase: kubeapi.JSONBase{ID: "build-docker-dataBuild"},
+		DesiredState: kubeapi.PodState{
+			Manifest: kubeapi.ContainerManifest{
+				Containers: []kubeapi.Container{{Name: "docker-build"}},
+			},
+		},
+		CurrentState: kubeapi.PodState{Host: host},
+	}
+}
+
+func TestReadBuildResult(t *testing.T) {
+	result := BuildResultMarker + `[{"Id": "abc123", "Parent": "def456", "Architecture": "amd64"}]` + "\n"
+	host, port, cleanup := newKubeletResultServer(t, result)
+	defer cleanup()
+
+	actual, err := NewKubeletResultReader(port, time.Second).ReadBuildResult(mockResultPod(host))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	image := actual.Image
+	if image == nil || image.ID != "abc123" || image.Parent != "def456" || image.Architecture != "amd64" {
+		t.Errorf("Unexpected image: %#v", image)
+	}
+}
+
+func TestReadBuildResultRevision(t *testing.T) {
+	result := BuildRevisionMarker + `{"commit": "0123abcd", "builderImageID": "def456"}` + "\n"
+	host, port, cleanup := newKubeletResultServer(t, result)
+	defer cleanup()
+
+	actual, err := NewKubeletResultReader(port, time.Second).ReadBuildResult(mockResultPod(host))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual.Image != nil {
+		t.Errorf("Expected no image, got %#v", actual.Image)
+	}
+	if actual.Revision == nil || actual.Revision.Commit != "0123abcd" || actual.Revision.BuilderImageID != "def456" {
+		t.Errorf("Unexpected revision: %#v", actual.Revision)
+	}
+}
+
+func TestReadBuildResultMissing(t *testing.T) {
+	host, port, cleanup := newKubeletResultServer(t, "")
+	defer cleanup()
+
+	if _, err := NewKubeletResultReader(port, time.Second).ReadBuildResult(mockResultPod(host)); err == nil {
+		t.Errorf("Expected an error when the builder reports no result")
+	}
+}
+
+func TestReadBuildResultTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	serverURL, _ := url.Parse(server.URL)
+	parts := strings.Split(serverURL.Host, ":")
+	port, _ := strconv.Atoi(parts[1])
+
+	done := make(chan error)
+	go func() {
+		_, err := NewKubeletResultReader(port, 10*time.Millisecond).ReadBuildResult(mockResultPod(parts[0]))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("Expected an error from an unresponsive kubelet")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected reading the result to time out")
+	}
+}
+
+func TestParseBuildResultInvalid(t *testing.T) {
+	for _, result := range []string{"", "{}", "[]", `[{"Id": "a"}, {"Id": "b"}]`} {
+		if _, err := parseBuildResult(result); err == nil {
+			t.Errorf("Expected an error for result %q", result)
+		}
+	}
+}
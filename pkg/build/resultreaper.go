@@ -0,0 +1,53 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/golang/glog"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// ResultDirReaper removes the result directories which build pods leave on
+// the host of a node under strategy.BuildResultHostDir, as the kubelet does not
+// remove the host directories of deleted pods. A result directory is removed
+// once it has not been modified for the TTL of build pods, by which time the
+// BuildPodReaper deletes the pod.
+type ResultDirReaper struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewResultDirReaper creates a new reaper of the result directories under dir.
+func NewResultDirReaper(dir string, ttl time.Duration) *ResultDirReaper {
+	return &ResultDirReaper{dir: dir, ttl: ttl}
+}
+
+// Run removes result directories immediately and then every period, until
+// stop is closed.
+func (r *ResultDirReaper) Run(period time.Duration, stop <-chan struct{}) {
+	go buildutil.Until(r.reap, period, stop)
+}
+
+// reap removes the result directories which are no longer needed.
+func (r *ResultDirReaper) reap() {
+	entries, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.Errorf("Error listing the build result directories in %s: %v", r.dir, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || time.Since(entry.ModTime()) <= r.ttl {
+			continue
+		}
+		dir := path.Join(r.dir, entry.Name())
+		glog.Infof("Removing the build result directory %s, last modified at %v", dir, entry.ModTime())
+		if err := os.RemoveAll(dir); err != nil {
+			glog.Errorf("Error removing the build result directory %s: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestReapResultDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-results")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"build-docker-recent", "build-docker-expired"} {
+		if err := os.MkdirAll(path.Join(dir, name), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, name, "result"), []byte("result"), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expired := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path.Join(dir, "build-docker-expired"), expired, expired); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	NewResultDirReaper(dir, time.Hour).reap()
+
+	if _, err := os.Stat(path.Join(dir, "build-docker-expired")); !os.IsNotExist(err) {
+		t.Errorf("Expected the expired result directory to be removed, got %v", err)
+	}
+	if _, err := os.Stat(path.Join(dir, "build-docker-recent", "result")); err != nil {
+		t.Errorf("Expected the recent result directory to be kept, got %v", err)
+	}
+}
+
+func TestReapResultDirsMissingDir(t *testing.T) {
+	NewResultDirReaper("/nonexistent/openshift-builds", time.Hour).reap()
+}
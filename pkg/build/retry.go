@@ -0,0 +1,84 @@
+package build
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// maxRetryDelaySeconds bounds the backoff of retry policies without a cap.
+const maxRetryDelaySeconds = 24 * 60 * 60
+
+// retry decides the status of a build which ended in BuildError. A build whose
+// pod could not be created or disappeared becomes pending again if the retry
+// policy of its configuration allows another attempt, and recreates its pod
+// once the backoff delay has elapsed. Any other build stays in BuildError.
+func (bc *BuildController) retry(build *api.Build) api.BuildStatus {
+	if !isTransientError(build.Reason) {
+		return api.BuildError
+	}
+	policy, err := bc.retryPolicy(build)
+	if err != nil {
+		glog.Errorf("Error retrieving the retry policy of build ID %v: %v", build.ID, err)
+		return api.BuildError
+	}
+	attempt := build.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return api.BuildError
+	}
+
+	delay := retryDelay(policy, attempt)
+	glog.Infof("Retrying build ID %v in %v after error: %s", build.ID, delay, build.Message)
+	build.Message = fmt.Sprintf("Attempt %d of %d will start in %v, the previous attempt ended with: %s",
+		attempt+1, policy.MaxAttempts, delay, build.Message)
+	build.Reason = ""
+	build.Attempt = attempt + 1
+	build.RetryTimestamp = util.Time{Time: time.Now().Add(delay)}
+	build.StartTimestamp = util.Time{}
+	return api.BuildPending
+}
+
+// retryPolicy returns the retry policy of the configuration which created the
+// build, or nil if the build has none.
+func (bc *BuildController) retryPolicy(build *api.Build) (*api.BuildRetryPolicy, error) {
+	if build.Config == nil {
+		return nil, nil
+	}
+	config, err := bc.osClient.GetBuildConfig(build.Config.ID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return config.RetryPolicy, nil
+}
+
+// retryDelay returns the delay before the retry following the given attempt:
+// BackoffSeconds after the first attempt, doubling with every further attempt.
+func retryDelay(policy *api.BuildRetryPolicy, attempt int) time.Duration {
+	limit := policy.MaxBackoffSeconds
+	if limit <= 0 || limit > maxRetryDelaySeconds {
+		limit = maxRetryDelaySeconds
+	}
+	delay := policy.BackoffSeconds
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return time.Duration(delay) * time.Second
+}
+
+// isTransientError returns true if a build in BuildError for the given reason
+// may succeed when executed again.
+func isTransientError(reason api.BuildStatusReason) bool {
+	return reason == api.BuildReasonPodCreationFailed || reason == api.BuildReasonPodDeleted
+}
@@ -0,0 +1,186 @@
+package build
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func retryController(policy *api.BuildRetryPolicy) (*BuildController, *queueOsClient, *api.Build) {
+	ctrl, build := setup()
+	build.Config = &api.BuildConfigReference{ID: "config", Version: 1}
+	client := &queueOsClient{
+		configs: map[string]*api.BuildConfig{
+			"config": {JSONBase: kubeapi.JSONBase{ID: "config"}, RetryPolicy: policy},
+		},
+	}
+	ctrl.osClient = client
+	return ctrl, client, build
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := &api.BuildRetryPolicy{MaxAttempts: 10, BackoffSeconds: 10, MaxBackoffSeconds: 60}
+	expected := map[int]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		4: 60 * time.Second,
+		5: 60 * time.Second,
+	}
+	for attempt, delay := range expected {
+		if actual := retryDelay(policy, attempt); actual != delay {
+			t.Errorf("Expected a delay of %v after attempt %d, got %v", delay, attempt, actual)
+		}
+	}
+
+	uncapped := &api.BuildRetryPolicy{MaxAttempts: 100, BackoffSeconds: 10}
+	if actual := retryDelay(uncapped, 100); actual != maxRetryDelaySeconds*time.Second {
+		t.Errorf("Expected the delay to be bounded, got %v", actual)
+	}
+}
+
+func TestSyncBuildRetriesDeletedPod(t *testing.T) {
+	ctrl, client, build := retryController(&api.BuildRetryPolicy{MaxAttempts: 3, BackoffSeconds: 30})
+	ctrl.kubeClient = &missingPodKubeClient{}
+	build.Status = api.BuildRunning
+	build.Attempt = 1
+	build.StartTimestamp.Time = time.Now()
+	client.builds = []api.Build{*build}
+
+	ctrl.syncBuild(build)
+
+	if build.Status != api.BuildPending {
+		t.Fatalf("Expected BuildPending, got %s", build.Status)
+	}
+	if build.Attempt != 2 {
+		t.Errorf("Expected attempt 2, got %d", build.Attempt)
+	}
+	if len(build.Reason) != 0 || !strings.Contains(build.Message, "Attempt 2 of 3") {
+		t.Errorf("Unexpected reason %s and message %q", build.Reason, build.Message)
+	}
+	if !build.StartTimestamp.IsZero() {
+		t.Errorf("Expected the start timestamp to be reset")
+	}
+	if delay := build.RetryTimestamp.Sub(time.Now()); delay <= 20*time.Second || delay > 30*time.Second {
+		t.Errorf("Expected a retry in 30 seconds, got %v", delay)
+	}
+	if client.builds[0].Status != api.BuildPending {
+		t.Errorf("Expected the retried build to be persisted, got %s", client.builds[0].Status)
+	}
+}
+
+func TestSynchronizeBuildPendingWaitsForRetry(t *testing.T) {
+	ctrl, build := setup()
+	kubeClient := &kubeclient.Fake{}
+	ctrl.kubeClient = kubeClient
+	build.Status = api.BuildPending
+	build.Attempt = 2
+	build.RetryTimestamp.Time = time.Now().Add(time.Minute)
+
+	status, err := ctrl.synchronize(build)
+	if err != nil || status != api.BuildPending {
+		t.Errorf("Expected BuildPending, got %s: %v", status, err)
+	}
+	if len(kubeClient.Actions) != 0 {
+		t.Errorf("Expected no pod to be created before the retry, got %v", kubeClient.Actions)
+	}
+
+	build.RetryTimestamp.Time = time.Now().Add(-time.Second)
+	status, err = ctrl.synchronize(build)
+	if err != nil || status != api.BuildRunning {
+		t.Errorf("Expected BuildRunning, got %s: %v", status, err)
+	}
+}
+
+func TestSyncBuildRetriesExhausted(t *testing.T) {
+	ctrl, _, build := retryController(&api.BuildRetryPolicy{MaxAttempts: 3, BackoffSeconds: 30})
+	ctrl.kubeClient = &errKubeClient{}
+	build.Status = api.BuildPending
+	build.Attempt = 3
+
+	ctrl.syncBuild(build)
+
+	if build.Status != api.BuildError || build.Reason != api.BuildReasonPodCreationFailed {
+		t.Errorf("Expected BuildError with reason %s, got %s: %s", api.BuildReasonPodCreationFailed, build.Status, build.Reason)
+	}
+	if build.Attempt != 3 {
+		t.Errorf("Expected attempt 3, got %d", build.Attempt)
+	}
+}
+
+func TestSyncBuildDoesNotRetryPermanentErrors(t *testing.T) {
+	ctrl, _, build := retryController(&api.BuildRetryPolicy{MaxAttempts: 3})
+	ctrl.buildStrategies = map[api.BuildType]BuildJobStrategy{}
+	build.Status = api.BuildPending
+	build.Attempt = 1
+
+	ctrl.syncBuild(build)
+
+	if build.Status != api.BuildError || build.Reason != api.BuildReasonUnknownStrategy {
+		t.Errorf("Expected BuildError with reason %s, got %s: %s", api.BuildReasonUnknownStrategy, build.Status, build.Reason)
+	}
+}
+
+func TestSyncBuildDoesNotRetryFailures(t *testing.T) {
+	ctrl, _, build := retryController(&api.BuildRetryPolicy{MaxAttempts: 3})
+	ctrl.kubeClient = &failedContainerKubeClient{}
+	build.Status = api.BuildRunning
+	build.Attempt = 1
+
+	ctrl.syncBuild(build)
+
+	if build.Status != api.BuildFailed {
+		t.Errorf("Expected BuildFailed, got %s", build.Status)
+	}
+}
+
+func TestSyncBuildWithoutRetryPolicy(t *testing.T) {
+	ctrl, _, build := retryController(nil)
+	ctrl.kubeClient = &missingPodKubeClient{}
+	build.Status = api.BuildRunning
+
+	ctrl.syncBuild(build)
+
+	if build.Status != api.BuildError {
+		t.Errorf("Expected BuildError, got %s", build.Status)
+	}
+}
+
+func TestSyncBuildRetriedBuildCompletes(t *testing.T) {
+	ctrl, client, build := retryController(&api.BuildRetryPolicy{MaxAttempts: 3, BackoffSeconds: 30})
+	ctrl.kubeClient = &missingPodKubeClient{}
+	ctrl.resultReader = &okResultReader{}
+	build.Status = api.BuildRunning
+	build.Attempt = 1
+	client.builds = []api.Build{*build}
+
+	ctrl.syncBuild(build)
+	if build.Status != api.BuildPending || len(build.Message) == 0 {
+		t.Fatalf("Expected a retry, got %s: %q", build.Status, build.Message)
+	}
+
+	ctrl.kubeClient = &okKubeClient{}
+	build.RetryTimestamp.Time = time.Now().Add(-time.Second)
+	ctrl.syncBuild(build)
+	if build.Status != api.BuildRunning {
+		t.Fatalf("Expected BuildRunning, got %s", build.Status)
+	}
+	if len(build.Reason) != 0 || len(build.Message) != 0 {
+		t.Errorf("Expected the message of the previous attempt to be cleared, got %s: %q", build.Reason, build.Message)
+	}
+
+	ctrl.syncBuild(build)
+	if build.Status != api.BuildComplete {
+		t.Fatalf("Expected BuildComplete, got %s", build.Status)
+	}
+	if len(build.Reason) != 0 || len(build.Message) != 0 {
+		t.Errorf("Expected no message on the complete build, got %s: %q", build.Reason, build.Message)
+	}
+	if client.builds[0].Attempt != 2 || len(client.builds[0].Message) != 0 {
+		t.Errorf("Unexpected persisted build: %#v", client.builds[0])
+	}
+}
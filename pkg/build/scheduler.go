@@ -0,0 +1,71 @@
+package build
+
+import (
+	"fmt"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// MinionSelectingScheduler schedules build pods only on the minions listed in
+// the pod options of their build, leaving the choice among those minions to
+// another scheduler. Pods which are not build pods are passed through, and so
+// are build pods whose build cannot be retrieved.
+type MinionSelectingScheduler struct {
+	delegate scheduler.Scheduler
+	osClient osclient.Interface
+}
+
+// NewMinionSelectingScheduler creates a new MinionSelectingScheduler
+func NewMinionSelectingScheduler(delegate scheduler.Scheduler, oc osclient.Interface) *MinionSelectingScheduler {
+	return &MinionSelectingScheduler{delegate: delegate, osClient: oc}
+}
+
+// Schedule selects the minion the pod will run on.
+func (s *MinionSelectingScheduler) Schedule(pod kubeapi.Pod, minionLister scheduler.MinionLister) (string, error) {
+	id, ok := pod.Labels[api.BuildLabel]
+	if !ok || !isBuildPodID(pod.ID, id) {
+		return s.delegate.Schedule(pod, minionLister)
+	}
+	build, err := s.osClient.GetBuild(id)
+	if err != nil {
+		// a build pod whose build cannot be retrieved is scheduled like any
+		// other pod rather than not at all
+		if !isNotFound(err) {
+			glog.Errorf("Error retrieving build ID %v, scheduling pod %s on any minion: %v", id, pod.ID, err)
+		}
+		return s.delegate.Schedule(pod, minionLister)
+	}
+	options := build.Input.PodOptions
+	if options == nil || len(options.Minions) == 0 {
+		return s.delegate.Schedule(pod, minionLister)
+	}
+
+	minions, err := minionLister.List()
+	if err != nil {
+		return "", err
+	}
+	selected := minionList{}
+	for _, minion := range minions {
+		for _, allowed := range options.Minions {
+			if minion == allowed {
+				selected = append(selected, minion)
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return "", fmt.Errorf("none of the minions %v selected by build %s is available", options.Minions, build.ID)
+	}
+	return s.delegate.Schedule(pod, selected)
+}
+
+// minionList is a scheduler.MinionLister over a fixed list of minions.
+type minionList []string
+
+func (l minionList) List() ([]string, error) {
+	return []string(l), nil
+}
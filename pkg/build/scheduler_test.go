@@ -0,0 +1,120 @@
+package build
+
+import (
+	"errors"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/openshift/origin/pkg/build/api"
+	osclient "github.com/openshift/origin/pkg/client"
+)
+
+// firstMinionScheduler schedules every pod on the first minion it is offered.
+type firstMinionScheduler struct {
+	offered []string
+}
+
+func (s *firstMinionScheduler) Schedule(pod kubeapi.Pod, minionLister scheduler.MinionLister) (string, error) {
+	minions, err := minionLister.List()
+	if err != nil {
+		return "", err
+	}
+	s.offered = minions
+	if len(minions) == 0 {
+		return "", errors.New("no minions")
+	}
+	return minions[0], nil
+}
+
+type schedulerOsClient struct {
+	osclient.Fake
+	build *api.Build
+	err   error
+}
+
+func (c *schedulerOsClient) GetBuild(id string) (*api.Build, error) {
+	return c.build, c.err
+}
+
+func buildPod(id string) kubeapi.Pod {
+	return kubeapi.Pod{
+		JSONBase: kubeapi.JSONBase{ID: "build-docker-" + id},
+		Labels:   map[string]string{api.BuildLabel: id},
+	}
+}
+
+func TestMinionSelectingSchedulerRestrictsBuildPods(t *testing.T) {
+	delegate := &firstMinionScheduler{}
+	client := &schedulerOsClient{build: &api.Build{
+		JSONBase: kubeapi.JSONBase{ID: "dataBuild"},
+		Input: api.BuildInput{
+			PodOptions: &api.BuildPodOptions{Minions: []string{"build2", "build1"}},
+		},
+	}}
+	s := NewMinionSelectingScheduler(delegate, client)
+
+	minion, err := s.Schedule(buildPod("dataBuild"), scheduler.FakeMinionLister{"app1", "build1", "app2", "build2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if minion != "build1" {
+		t.Errorf("Expected build1, got %s", minion)
+	}
+	if len(delegate.offered) != 2 {
+		t.Errorf("Expected only the build minions to be offered, got %v", delegate.offered)
+	}
+}
+
+func TestMinionSelectingSchedulerNoSelectedMinion(t *testing.T) {
+	client := &schedulerOsClient{build: &api.Build{
+		Input: api.BuildInput{
+			PodOptions: &api.BuildPodOptions{Minions: []string{"build1"}},
+		},
+	}}
+	s := NewMinionSelectingScheduler(&firstMinionScheduler{}, client)
+
+	if _, err := s.Schedule(buildPod("dataBuild"), scheduler.FakeMinionLister{"app1"}); err == nil {
+		t.Errorf("Expected an error when none of the selected minions is available")
+	}
+}
+
+func TestMinionSelectingSchedulerPassesThrough(t *testing.T) {
+	tests := map[string]struct {
+		pod    kubeapi.Pod
+		client *schedulerOsClient
+	}{
+		"not a build pod": {
+			pod:    kubeapi.Pod{},
+			client: &schedulerOsClient{err: errors.New("unexpected call")},
+		},
+		"build without pod options": {
+			pod:    buildPod("dataBuild"),
+			client: &schedulerOsClient{build: &api.Build{}},
+		},
+		"not named like a build pod": {
+			pod:    kubeapi.Pod{JSONBase: kubeapi.JSONBase{ID: "frontend"}, Labels: map[string]string{api.BuildLabel: "dataBuild"}},
+			client: &schedulerOsClient{err: errors.New("unexpected call")},
+		},
+		"build not found": {
+			pod:    buildPod("dataBuild"),
+			client: &schedulerOsClient{err: kubeerrors.NewNotFound("build", "dataBuild")},
+		},
+		"API unavailable": {
+			pod:    buildPod("dataBuild"),
+			client: &schedulerOsClient{err: errors.New("connection refused")},
+		},
+	}
+	for desc, test := range tests {
+		delegate := &firstMinionScheduler{}
+		s := NewMinionSelectingScheduler(delegate, test.client)
+		minion, err := s.Schedule(test.pod, scheduler.FakeMinionLister{"app1", "build1"})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", desc, err)
+		}
+		if minion != "app1" || len(delegate.offered) != 2 {
+			t.Errorf("%s: expected all minions to be offered, got %s from %v", desc, minion, delegate.offered)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package strategy
+
+import (
+	"errors"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	buildapi "github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// CustomBuildStrategy creates builds executed by a builder image supplied
+// by the user in the custom input of the build
+type CustomBuildStrategy struct{}
+
+// NewCustomBuildStrategy creates a new CustomBuildStrategy
+func NewCustomBuildStrategy() *CustomBuildStrategy {
+	return &CustomBuildStrategy{}
+}
+
+// CreateBuildPod creates a pod that runs the custom builder image. The serialized
+// build is passed to the builder in the BUILD environment variable.
+func (bs *CustomBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry string, credentials *CredentialsSource) (*api.Pod, error) {
+	custom := build.Input.Custom
+	if custom == nil {
+		return nil, errors.New("custom build input is not set")
+	}
+	data, err := runtime.Encode(build)
+	if err != nil {
+		return nil, err
+	}
+
+	env := []api.EnvVar{
+		{Name: "BUILD", Value: string(data)},
+		{Name: "BUILD_TAG", Value: build.Input.ImageTag},
+		{Name: "DOCKER_REGISTRY", Value: dockerRegistry},
+	}
+
+	pod := &api.Pod{
+		JSONBase: api.JSONBase{
+			ID: build.PodID,
+		},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Version: "v1beta1",
+				Containers: []api.Container{
+					{
+						Name:          "custom-build",
+						Image:         custom.Image,
+						RestartPolicy: "runOnce",
+						Env:           env,
+					},
+				},
+			},
+		},
+	}
+	if err := setupSource(&build.Input.Source, pod); err != nil {
+		return nil, err
+	}
+	setupBuildEnv(build, pod)
+	// the custom environment takes precedence over the variables of the strategy
+	pod.DesiredState.Manifest.Containers[0].Env =
+		buildutil.MergeEnv(pod.DesiredState.Manifest.Containers[0].Env, custom.Env)
+	setupBuildPod(build, pod)
+	setupCredentials(credentials, pod)
+	setupBuildResult(pod)
+	if custom.ExposeDockerSocket {
+		setupDockerSocket(true, pod)
+	}
+	return pod, nil
+}
@@ -0,0 +1,111 @@
+package strategy
+
+import (
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/openshift/origin/pkg/build/api"
+	_ "github.com/openshift/origin/pkg/build/api/v1beta1"
+)
+
+func TestCustomCreateBuildPod(t *testing.T) {
+	const dockerRegistry = "custom-test-registry"
+	strategy := NewCustomBuildStrategy()
+	expected := mockCustomBuild()
+	actual, err := strategy.CreateBuildPod(expected, dockerRegistry, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if actual.JSONBase.ID != expected.PodID {
+		t.Errorf("Expected %s, but got %s!", expected.PodID, actual.JSONBase.ID)
+	}
+	container := actual.DesiredState.Manifest.Containers[0]
+	if container.Name != "custom-build" {
+		t.Errorf("Expected custom-build, but got %s!", container.Name)
+	}
+	if container.Image != expected.Input.Custom.Image {
+		t.Errorf("Expected %s image, got %s!", expected.Input.Custom.Image, container.Image)
+	}
+	if container.RestartPolicy != "runOnce" {
+		t.Errorf("Expected runOnce, but got %s!", container.RestartPolicy)
+	}
+	if container.Privileged {
+		t.Errorf("Expected the custom builder not to be privileged")
+	}
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].Name != "build-result" {
+		t.Errorf("Expected only the build result to be mounted, got %#v", container.VolumeMounts)
+	}
+
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if e, a := dockerRegistry, env["DOCKER_REGISTRY"]; e != a {
+		t.Errorf("Expected DOCKER_REGISTRY %s, got %s", e, a)
+	}
+	if e, a := expected.Input.Source.Archive.URL, env["SOURCE_URI"]; e != a {
+		t.Errorf("Expected SOURCE_URI %s, got %s", e, a)
+	}
+	if e, a := "clean install", env["GRADLE_TASKS"]; e != a {
+		t.Errorf("Expected GRADLE_TASKS %s, got %s", e, a)
+	}
+	build := api.Build{}
+	if err := runtime.DecodeInto([]byte(env["BUILD"]), &build); err != nil {
+		t.Fatalf("Unable to decode the serialized build: %v", err)
+	}
+	if build.ID != expected.ID || build.Input.Custom == nil || build.Input.Custom.Image != expected.Input.Custom.Image {
+		t.Errorf("Unexpected serialized build: %#v", build)
+	}
+}
+
+func TestCustomCreateBuildPodExposeDockerSocket(t *testing.T) {
+	strategy := NewCustomBuildStrategy()
+	build := mockCustomBuild()
+	build.Input.Custom.ExposeDockerSocket = true
+	actual, err := strategy.CreateBuildPod(build, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	container := actual.DesiredState.Manifest.Containers[0]
+	if len(container.VolumeMounts) != 2 || container.VolumeMounts[1].MountPath != "/var/run/docker.sock" {
+		t.Errorf("Expected the docker socket to be mounted, got %#v", container.VolumeMounts)
+	}
+}
+
+func TestCustomCreateBuildPodMissingInput(t *testing.T) {
+	strategy := NewCustomBuildStrategy()
+	build := mockCustomBuild()
+	build.Input.Custom = nil
+	if _, err := strategy.CreateBuildPod(build, "", nil); err == nil {
+		t.Errorf("Expected an error for a build without custom input")
+	}
+}
+
+func mockCustomBuild() *api.Build {
+	return &api.Build{
+		JSONBase: kubeapi.JSONBase{
+			ID: "customBuild",
+		},
+		Input: api.BuildInput{
+			Type: api.CustomBuildType,
+			Source: api.BuildSource{
+				Type:    api.ArchiveBuildSourceType,
+				Archive: &api.ArchiveBuildSource{URL: "http://my.build.com/the/custombuild.tar.gz"},
+			},
+			ImageTag: "repository/customBuild",
+			Custom: &api.CustomBuildInput{
+				Image: "builder/gradle",
+				Env: []kubeapi.EnvVar{
+					{Name: "GRADLE_TASKS", Value: "clean install"},
+				},
+			},
+		},
+		Status: api.BuildNew,
+		PodID:  "-the-pod-id",
+		Labels: map[string]string{
+			"name": "customBuild",
+		},
+	}
+}
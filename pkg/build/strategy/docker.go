@@ -19,8 +19,7 @@ func NewDockerBuildStrategy(dockerBuilderImage string, useHostDocker bool) *Dock
 }
 
 // CreateBuildPod creates the pod to be used for the Docker build
-// TODO: Make the Pod definition configurable
-func (bs *DockerBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry string) *api.Pod {
+func (bs *DockerBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry string, credentials *CredentialsSource) (*api.Pod, error) {
 	pod := &api.Pod{
 		JSONBase: api.JSONBase{
 			ID: build.PodID,
@@ -35,7 +34,6 @@ func (bs *DockerBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegis
 						RestartPolicy: "runOnce",
 						Env: []api.EnvVar{
 							{Name: "BUILD_TAG", Value: build.Input.ImageTag},
-							{Name: "DOCKER_CONTEXT_URL", Value: build.Input.SourceURI},
 							{Name: "DOCKER_REGISTRY", Value: dockerRegistry},
 						},
 						Privileged: true,
@@ -45,6 +43,13 @@ func (bs *DockerBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegis
 		},
 	}
 
+	if err := setupSource(&build.Input.Source, pod); err != nil {
+		return nil, err
+	}
+	setupBuildEnv(build, pod)
+	setupBuildPod(build, pod)
+	setupCredentials(credentials, pod)
+	setupBuildResult(pod)
 	setupDockerSocket(bs.useHostDocker, pod)
-	return pod
+	return pod, nil
 }
@@ -11,7 +11,10 @@ func TestDockerCreateBuildPod(t *testing.T) {
 	const dockerRegistry = "docker-test-registry"
 	strategy := NewDockerBuildStrategy("docker-test-image", false)
 	expected := mockDockerBuild()
-	actual := strategy.CreateBuildPod(expected, dockerRegistry)
+	actual, err := strategy.CreateBuildPod(expected, dockerRegistry, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if actual.JSONBase.ID != expected.PodID {
 		t.Errorf("Expected %s, but got %s!", expected.PodID, actual.JSONBase.ID)
@@ -36,12 +39,24 @@ func TestDockerCreateBuildPod(t *testing.T) {
 	if e := container.Env[0]; e.Name != "BUILD_TAG" && e.Value != expected.Input.ImageTag {
 		t.Errorf("Expected %s, got %s:%s!", expected.Input.ImageTag, e.Name, e.Value)
 	}
-	if e := container.Env[1]; e.Name != "DOCKER_CONTEXT_URL" && e.Value != expected.Input.SourceURI {
-		t.Errorf("Expected %s, got %s:%s!", expected.Input.ImageTag, e.Name, e.Value)
-	}
-	if e := container.Env[2]; e.Name != "DOCKER_REGISTRY" && e.Value != dockerRegistry {
+	if e := container.Env[1]; e.Name != "DOCKER_REGISTRY" || e.Value != dockerRegistry {
 		t.Errorf("Expected %s got %s:%s!", dockerRegistry, e.Name, e.Value)
 	}
+	if e := container.Env[2]; e.Name != "SOURCE_TYPE" || e.Value != string(api.GitBuildSourceType) {
+		t.Errorf("Expected %s got %s:%s!", api.GitBuildSourceType, e.Name, e.Value)
+	}
+	if e := container.Env[5]; e.Name != "CONTEXT_DIR" || e.Value != expected.Input.Source.Git.ContextDir {
+		t.Errorf("Expected %s got %s:%s!", expected.Input.Source.Git.ContextDir, e.Name, e.Value)
+	}
+}
+
+func TestDockerCreateBuildPodInvalidSource(t *testing.T) {
+	strategy := NewDockerBuildStrategy("docker-test-image", false)
+	build := mockDockerBuild()
+	build.Input.Source.Git = nil
+	if _, err := strategy.CreateBuildPod(build, "", nil); err == nil {
+		t.Errorf("Expected an error for a build without git source")
+	}
 }
 
 func mockDockerBuild() *api.Build {
@@ -50,9 +65,16 @@ func mockDockerBuild() *api.Build {
 			ID: "dockerBuild",
 		},
 		Input: api.BuildInput{
-			Type:      api.DockerBuildType,
-			SourceURI: "http://my.build.com/the/dockerbuild/Dockerfile",
-			ImageTag:  "repository/dockerBuild",
+			Type: api.DockerBuildType,
+			Source: api.BuildSource{
+				Type: api.GitBuildSourceType,
+				Git: &api.GitBuildSource{
+					URI:        "http://my.build.com/the/dockerbuild",
+					Ref:        "master",
+					ContextDir: "services/web",
+				},
+			},
+			ImageTag: "repository/dockerBuild",
 		},
 		Status: api.BuildNew,
 		PodID:  "-the-pod-id",
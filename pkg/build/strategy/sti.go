@@ -1,6 +1,8 @@
 package strategy
 
 import (
+	"strings"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	buildapi "github.com/openshift/origin/pkg/build/api"
 )
@@ -20,8 +22,7 @@ func NewSTIBuildStrategy(stiBuilderImage string, useHostDocker bool) *STIBuildSt
 }
 
 // CreateBuildPod creates a pod that will execute the STI build
-// TODO: Make the Pod definition configurable
-func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry string) *api.Pod {
+func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry string, credentials *CredentialsSource) (*api.Pod, error) {
 	pod := &api.Pod{
 		JSONBase: api.JSONBase{
 			ID: build.PodID,
@@ -37,9 +38,8 @@ func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry
 						Env: []api.EnvVar{
 							{Name: "BUILD_TAG", Value: build.Input.ImageTag},
 							{Name: "DOCKER_REGISTRY", Value: dockerRegistry},
-							{Name: "SOURCE_URI", Value: build.Input.SourceURI},
-							{Name: "SOURCE_REF", Value: build.Input.SourceRef},
 							{Name: "BUILDER_IMAGE", Value: build.Input.BuilderImage},
+							{Name: "STI_ENV_NAMES", Value: stiEnvNames(build.Input.Env)},
 						},
 						Privileged: true,
 					},
@@ -47,6 +47,29 @@ func (bs *STIBuildStrategy) CreateBuildPod(build *buildapi.Build, dockerRegistry
 			},
 		},
 	}
+	if build.Revision != nil && len(build.Revision.BuilderImageID) > 0 {
+		// a re-run of a build must use the builder image of the original build
+		container := &pod.DesiredState.Manifest.Containers[0]
+		container.Env = append(container.Env, api.EnvVar{Name: "BUILDER_IMAGE_ID", Value: build.Revision.BuilderImageID})
+	}
+	if err := setupSource(&build.Input.Source, pod); err != nil {
+		return nil, err
+	}
+	setupBuildEnv(build, pod)
+	setupBuildPod(build, pod)
+	setupCredentials(credentials, pod)
+	setupBuildResult(pod)
 	setupDockerSocket(bs.useHostDocker, pod)
-	return pod
+	return pod, nil
+}
+
+// stiEnvNames lists the names of the environment variables of the build. The
+// builder passes each of them to sti in its own --env option, which forwards
+// it to the assemble script of the builder image.
+func stiEnvNames(env []api.EnvVar) string {
+	names := make([]string, 0, len(env))
+	for _, v := range env {
+		names = append(names, v.Name)
+	}
+	return strings.Join(names, " ")
 }
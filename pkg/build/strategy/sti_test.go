@@ -11,7 +11,10 @@ func TestSTICreateBuildPod(t *testing.T) {
 	const dockerRegistry = "sti-test-registry"
 	strategy := NewSTIBuildStrategy("sti-test-image", false)
 	expected := mockSTIBuild()
-	actual := strategy.CreateBuildPod(expected, dockerRegistry)
+	actual, err := strategy.CreateBuildPod(expected, dockerRegistry, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if actual.JSONBase.ID != expected.PodID {
 		t.Errorf("Expected %s, but got %s!", expected.PodID, actual.JSONBase.ID)
@@ -39,12 +42,39 @@ func TestSTICreateBuildPod(t *testing.T) {
 	if e := container.Env[1]; e.Name != "DOCKER_REGISTRY" && e.Value != dockerRegistry {
 		t.Errorf("Expected %s got %s:%s!", dockerRegistry, e.Name, e.Value)
 	}
-	if e := container.Env[2]; e.Name != "SOURCE_REF" && e.Value != expected.Input.SourceURI {
-		t.Errorf("Expected %s got %s:%s!", expected.Input.SourceURI, e.Name, e.Value)
-	}
-	if e := container.Env[3]; e.Name != "BUILDER_IMAGE" && e.Value != expected.Input.BuilderImage {
+	if e := container.Env[2]; e.Name != "BUILDER_IMAGE" && e.Value != expected.Input.BuilderImage {
 		t.Errorf("Expected %s, got %s:%s!", expected.Input.BuilderImage, e.Name, e.Value)
 	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if e, a := expected.Input.Source.Git.URI, env["SOURCE_URI"]; e != a {
+		t.Errorf("Expected SOURCE_URI %s, got %s", e, a)
+	}
+	if e, a := "http://mirror.example.com", env["MAVEN_MIRROR_URL"]; e != a {
+		t.Errorf("Expected MAVEN_MIRROR_URL %s, got %s", e, a)
+	}
+	if e, a := "MAVEN_MIRROR_URL DEBUG", env["STI_ENV_NAMES"]; e != a {
+		t.Errorf("Expected STI_ENV_NAMES %s, got %s", e, a)
+	}
+}
+
+func TestSTICreateBuildPodPinnedBuilderImage(t *testing.T) {
+	strategy := NewSTIBuildStrategy("sti-test-image", false)
+	build := mockSTIBuild()
+	build.Revision = &api.BuildRevision{Commit: "0123abcd", BuilderImageID: "def456"}
+	actual, err := strategy.CreateBuildPod(build, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	env := map[string]string{}
+	for _, e := range actual.DesiredState.Manifest.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if e, a := "def456", env["BUILDER_IMAGE_ID"]; e != a {
+		t.Errorf("Expected BUILDER_IMAGE_ID %s, got %s", e, a)
+	}
 }
 
 func mockSTIBuild() *api.Build {
@@ -53,9 +83,16 @@ func mockSTIBuild() *api.Build {
 			ID: "stiBuild",
 		},
 		Input: api.BuildInput{
-			Type:      api.STIBuildType,
-			SourceURI: "http://my.build.com/the/stibuild/Dockerfile",
-			ImageTag:  "repository/stiBuild",
+			Type: api.STIBuildType,
+			Source: api.BuildSource{
+				Type: api.GitBuildSourceType,
+				Git:  &api.GitBuildSource{URI: "http://my.build.com/the/stibuild"},
+			},
+			Env: []kubeapi.EnvVar{
+				{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"},
+				{Name: "DEBUG", Value: "true"},
+			},
+			ImageTag: "repository/stiBuild",
 		},
 		Status: api.BuildNew,
 		PodID:  "-the-pod-id",
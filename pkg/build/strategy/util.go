@@ -1,9 +1,155 @@
 package strategy
 
 import (
+	"fmt"
+	"path"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	buildapi "github.com/openshift/origin/pkg/build/api"
 )
 
+// setupSource passes the source of the build to the builder container, which
+// fetches it into the build context. SOURCE_TYPE selects the variables to use:
+// SOURCE_URI, SOURCE_REF and CONTEXT_DIR for git, DOCKERFILE for an inline
+// Dockerfile and SOURCE_URI for an archive.
+func setupSource(source *buildapi.BuildSource, podSpec *api.Pod) error {
+	env := []api.EnvVar{{Name: "SOURCE_TYPE", Value: string(source.Type)}}
+	switch source.Type {
+	case buildapi.GitBuildSourceType:
+		if source.Git == nil {
+			return fmt.Errorf("git source is not set")
+		}
+		env = append(env,
+			api.EnvVar{Name: "SOURCE_URI", Value: source.Git.URI},
+			api.EnvVar{Name: "SOURCE_REF", Value: source.Git.Ref},
+			api.EnvVar{Name: "CONTEXT_DIR", Value: source.Git.ContextDir})
+	case buildapi.DockerfileBuildSourceType:
+		env = append(env, api.EnvVar{Name: "DOCKERFILE", Value: source.Dockerfile})
+	case buildapi.ArchiveBuildSourceType:
+		if source.Archive == nil {
+			return fmt.Errorf("archive source is not set")
+		}
+		env = append(env, api.EnvVar{Name: "SOURCE_URI", Value: source.Archive.URL})
+	default:
+		return fmt.Errorf("unsupported source type %q", source.Type)
+	}
+	podSpec.DesiredState.Manifest.Containers[0].Env =
+		append(podSpec.DesiredState.Manifest.Containers[0].Env, env...)
+	return nil
+}
+
+// ReservedEnvNames are the environment variables set by the strategies in the
+// builder container, and the ones the builder can not run without. Validation
+// rejects them in the environment of a build.
+var ReservedEnvNames = map[string]bool{
+	"BUILD":             true,
+	"BUILD_RESULT_FILE": true,
+	"BUILD_TAG":         true,
+	"BUILDER_IMAGE":     true,
+	"BUILDER_IMAGE_ID":  true,
+	"CONTEXT_DIR":       true,
+	"CREDENTIALS_TOKEN": true,
+	"CREDENTIALS_URL":   true,
+	"DOCKER_REGISTRY":   true,
+	"DOCKERFILE":        true,
+	"HOME":              true,
+	"PATH":              true,
+	"SOURCE_REF":        true,
+	"SOURCE_TYPE":       true,
+	"SOURCE_URI":        true,
+	"STI_ENV_NAMES":     true,
+}
+
+// setupBuildEnv passes the environment of the build to the builder container.
+// The variables set by the strategy take precedence over the build environment,
+// which validation keeps from using ReservedEnvNames.
+func setupBuildEnv(build *buildapi.Build, podSpec *api.Pod) {
+	container := &podSpec.DesiredState.Manifest.Containers[0]
+	set := make(map[string]bool, len(container.Env))
+	for _, v := range container.Env {
+		set[v.Name] = true
+	}
+	for _, v := range build.Input.Env {
+		if !set[v.Name] {
+			container.Env = append(container.Env, v)
+		}
+	}
+}
+
+// CredentialsSource tells the builder container where to fetch the credentials
+// of its build, which are never part of the pod definition.
+type CredentialsSource struct {
+	// URL serves the credentials as a tar archive of files
+	URL string
+	// Token authorizes a single fetch of the credentials
+	Token string
+}
+
+// setupCredentials passes the location of the credentials of the build to the
+// builder container, which fetches and installs them before fetching the source
+// and pushing the output image. The builder sends CREDENTIALS_TOKEN as a bearer
+// token to CREDENTIALS_URL.
+func setupCredentials(credentials *CredentialsSource, podSpec *api.Pod) {
+	if credentials == nil {
+		return
+	}
+	podSpec.DesiredState.Manifest.Containers[0].Env = append(podSpec.DesiredState.Manifest.Containers[0].Env,
+		api.EnvVar{Name: "CREDENTIALS_URL", Value: credentials.URL},
+		api.EnvVar{Name: "CREDENTIALS_TOKEN", Value: credentials.Token})
+}
+
+// setupBuildPod applies the pod options of the build to the pod and labels
+// the pod with the id of the build and of its BuildConfig.
+func setupBuildPod(build *buildapi.Build, podSpec *api.Pod) {
+	podSpec.Labels = make(map[string]string)
+	if options := build.Input.PodOptions; options != nil {
+		for key, value := range options.Labels {
+			podSpec.Labels[key] = value
+		}
+		podSpec.DesiredState.Manifest.Containers[0].Memory = options.Memory
+		podSpec.DesiredState.Manifest.Containers[0].CPU = options.CPU
+	}
+	podSpec.Labels[buildapi.BuildLabel] = build.ID
+	if build.Config != nil {
+		podSpec.Labels[buildapi.BuildConfigLabel] = build.Config.ID
+	}
+}
+
+// BuildResultHostDir is the directory of the node under which the builder
+// container of a build pod reports its result, in the BuildResultFile of a
+// directory named after the pod. It is below the /var/log directory which the
+// kubelet serves at /logs/, from which the build controller reads the result.
+const BuildResultHostDir = "/var/log/openshift-builds"
+
+// BuildResultFile is the name of the file reporting the result of a build.
+const BuildResultFile = "result"
+
+// buildResultMountPath is where the result directory of the pod is mounted in
+// the builder container.
+const buildResultMountPath = "/var/run/openshift-build"
+
+// setupBuildResult mounts the result directory of the pod in the builder
+// container and passes the path of the result file in BUILD_RESULT_FILE.
+func setupBuildResult(podSpec *api.Pod) {
+	podSpec.DesiredState.Manifest.Volumes = append(podSpec.DesiredState.Manifest.Volumes, api.Volume{
+		Name: "build-result",
+		Source: &api.VolumeSource{
+			HostDirectory: &api.HostDirectory{
+				Path: path.Join(BuildResultHostDir, podSpec.ID),
+			},
+		},
+	})
+	container := &podSpec.DesiredState.Manifest.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, api.VolumeMount{
+		Name:      "build-result",
+		MountPath: buildResultMountPath,
+	})
+	container.Env = append(container.Env, api.EnvVar{
+		Name:  "BUILD_RESULT_FILE",
+		Value: path.Join(buildResultMountPath, BuildResultFile),
+	})
+}
+
 // setupDockerSocket configures the pod to support either the host's Docker socket
 // or a Docker-in-Docker socket where Docker runs in the container itself.
 func setupDockerSocket(useHostDocker bool, podSpec *api.Pod) {
@@ -1,9 +1,11 @@
 package strategy
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	buildapi "github.com/openshift/origin/pkg/build/api"
 )
 
 func TestSetupDockerSocketHostSocket(t *testing.T) {
@@ -77,3 +79,229 @@ func TestSetupDockerSocketDockerInDocker(t *testing.T) {
 		t.Error("Expected privileged to be true")
 	}
 }
+
+func TestSetupBuildResult(t *testing.T) {
+	pod := api.Pod{
+		JSONBase: api.JSONBase{ID: "build-docker-myapp-3"},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{},
+				},
+			},
+		},
+	}
+
+	setupBuildResult(&pod)
+
+	volumes := pod.DesiredState.Manifest.Volumes
+	if len(volumes) != 1 || volumes[0].Source == nil || volumes[0].Source.HostDirectory == nil {
+		t.Fatalf("Expected a host directory volume, got %#v", volumes)
+	}
+	if e, a := "/var/log/openshift-builds/build-docker-myapp-3", volumes[0].Source.HostDirectory.Path; e != a {
+		t.Errorf("Expected host directory %s, got %s", e, a)
+	}
+	container := pod.DesiredState.Manifest.Containers[0]
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].Name != volumes[0].Name {
+		t.Fatalf("Expected the volume to be mounted, got %#v", container.VolumeMounts)
+	}
+	expected := []api.EnvVar{{Name: "BUILD_RESULT_FILE", Value: container.VolumeMounts[0].MountPath + "/result"}}
+	if !reflect.DeepEqual(expected, container.Env) {
+		t.Errorf("Expected env %v, got %v", expected, container.Env)
+	}
+}
+
+func TestSetupBuildPod(t *testing.T) {
+	build := &buildapi.Build{
+		JSONBase: api.JSONBase{ID: "myapp-3"},
+		Config:   &buildapi.BuildConfigReference{ID: "myapp", Version: 3},
+		Input: buildapi.BuildInput{
+			PodOptions: &buildapi.BuildPodOptions{
+				Memory: 512 * 1024 * 1024,
+				CPU:    500,
+				Labels: map[string]string{"team": "platform"},
+			},
+		},
+	}
+	pod := api.Pod{
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{},
+				},
+			},
+		},
+	}
+
+	setupBuildPod(build, &pod)
+
+	expected := map[string]string{
+		"team":                    "platform",
+		buildapi.BuildLabel:       "myapp-3",
+		buildapi.BuildConfigLabel: "myapp",
+	}
+	if !reflect.DeepEqual(expected, pod.Labels) {
+		t.Errorf("Expected labels %v, got %v", expected, pod.Labels)
+	}
+	container := pod.DesiredState.Manifest.Containers[0]
+	if container.Memory != 512*1024*1024 || container.CPU != 500 {
+		t.Errorf("Unexpected container resources: memory %d, cpu %d", container.Memory, container.CPU)
+	}
+}
+
+func TestSetupBuildPodWithoutOptions(t *testing.T) {
+	build := &buildapi.Build{JSONBase: api.JSONBase{ID: "dataBuild"}}
+	pod := api.Pod{
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{},
+				},
+			},
+		},
+	}
+
+	setupBuildPod(build, &pod)
+
+	expected := map[string]string{buildapi.BuildLabel: "dataBuild"}
+	if !reflect.DeepEqual(expected, pod.Labels) {
+		t.Errorf("Expected labels %v, got %v", expected, pod.Labels)
+	}
+}
+
+func TestSetupSource(t *testing.T) {
+	tests := map[string]struct {
+		source   buildapi.BuildSource
+		expected []api.EnvVar
+	}{
+		"git": {
+			source: buildapi.BuildSource{
+				Type: buildapi.GitBuildSourceType,
+				Git:  &buildapi.GitBuildSource{URI: "git://github.com/my/monorepo", Ref: "v1", ContextDir: "services/web"},
+			},
+			expected: []api.EnvVar{
+				{Name: "SOURCE_TYPE", Value: "git"},
+				{Name: "SOURCE_URI", Value: "git://github.com/my/monorepo"},
+				{Name: "SOURCE_REF", Value: "v1"},
+				{Name: "CONTEXT_DIR", Value: "services/web"},
+			},
+		},
+		"dockerfile": {
+			source: buildapi.BuildSource{
+				Type:       buildapi.DockerfileBuildSourceType,
+				Dockerfile: "FROM busybox",
+			},
+			expected: []api.EnvVar{
+				{Name: "SOURCE_TYPE", Value: "dockerfile"},
+				{Name: "DOCKERFILE", Value: "FROM busybox"},
+			},
+		},
+		"archive": {
+			source: buildapi.BuildSource{
+				Type:    buildapi.ArchiveBuildSourceType,
+				Archive: &buildapi.ArchiveBuildSource{URL: "http://example.com/app.tar.gz"},
+			},
+			expected: []api.EnvVar{
+				{Name: "SOURCE_TYPE", Value: "archive"},
+				{Name: "SOURCE_URI", Value: "http://example.com/app.tar.gz"},
+			},
+		},
+	}
+	for desc, test := range tests {
+		pod := api.Pod{
+			DesiredState: api.PodState{
+				Manifest: api.ContainerManifest{
+					Containers: []api.Container{
+						{},
+					},
+				},
+			},
+		}
+		if err := setupSource(&test.source, &pod); err != nil {
+			t.Errorf("%s: Unexpected error: %v", desc, err)
+			continue
+		}
+		if env := pod.DesiredState.Manifest.Containers[0].Env; !reflect.DeepEqual(test.expected, env) {
+			t.Errorf("%s: Expected env %v, got %v", desc, test.expected, env)
+		}
+	}
+}
+
+func TestSetupSourceInvalid(t *testing.T) {
+	sources := map[string]buildapi.BuildSource{
+		"no type":         {Dockerfile: "FROM busybox"},
+		"missing git":     {Type: buildapi.GitBuildSourceType},
+		"missing archive": {Type: buildapi.ArchiveBuildSourceType},
+	}
+	for desc, source := range sources {
+		pod := api.Pod{
+			DesiredState: api.PodState{
+				Manifest: api.ContainerManifest{
+					Containers: []api.Container{
+						{},
+					},
+				},
+			},
+		}
+		if err := setupSource(&source, &pod); err == nil {
+			t.Errorf("%s: Expected an error", desc)
+		}
+	}
+}
+
+func TestSetupBuildEnv(t *testing.T) {
+	build := &buildapi.Build{
+		Input: buildapi.BuildInput{
+			Env: []api.EnvVar{
+				{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"},
+				{Name: "BUILD_TAG", Value: "overridden"},
+			},
+		},
+	}
+	pod := api.Pod{
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{Env: []api.EnvVar{{Name: "BUILD_TAG", Value: "repository/data"}}},
+				},
+			},
+		},
+	}
+
+	setupBuildEnv(build, &pod)
+
+	expected := []api.EnvVar{
+		{Name: "BUILD_TAG", Value: "repository/data"},
+		{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"},
+	}
+	if env := pod.DesiredState.Manifest.Containers[0].Env; !reflect.DeepEqual(expected, env) {
+		t.Errorf("Expected env %v, got %v", expected, env)
+	}
+}
+
+func TestSetupCredentials(t *testing.T) {
+	pod := api.Pod{
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{Env: []api.EnvVar{{Name: "BUILD_TAG", Value: "repository/data"}}},
+				},
+			},
+		},
+	}
+	credentials := &CredentialsSource{
+		URL:   "http://master:8080/osapi/v1beta1/builds/dataBuild/credentials",
+		Token: "0123abcd",
+	}
+
+	setupCredentials(credentials, &pod)
+
+	expected := []api.EnvVar{
+		{Name: "BUILD_TAG", Value: "repository/data"},
+		{Name: "CREDENTIALS_URL", Value: "http://master:8080/osapi/v1beta1/builds/dataBuild/credentials"},
+		{Name: "CREDENTIALS_TOKEN", Value: "0123abcd"},
+	}
+	if env := pod.DesiredState.Manifest.Containers[0].Env; !reflect.DeepEqual(expected, env) {
+		t.Errorf("Expected env %v, got %v", expected, env)
+	}
+}
@@ -0,0 +1,144 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/golang/glog"
+	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
+)
+
+// triggerDownstreamBuilds creates a new build of every BuildConfig which lists
+// the BuildConfig of build as upstream, and clears DownstreamPending once they
+// all exist. It is called once the build has been stored with status
+// BuildComplete, and again by synchronizeAll while DownstreamPending is set.
+func (bc *BuildController) triggerDownstreamBuilds(build *api.Build) {
+	if !build.DownstreamPending {
+		return
+	}
+	if err := bc.createDownstreamBuilds(build); err != nil {
+		glog.Errorf("Error triggering the builds downstream of build %s, retrying later: %v", build.ID, err)
+		return
+	}
+
+	current, err := bc.osClient.GetBuild(build.ID)
+	if err != nil {
+		glog.Errorf("Error reading build %s after triggering its downstream builds: %v", build.ID, err)
+		return
+	}
+	current.DownstreamPending = false
+	if _, err := bc.osClient.UpdateBuild(current); err != nil {
+		// the downstream builds which were created are not created again
+		glog.Errorf("Error updating build %s after triggering its downstream builds: %v", build.ID, err)
+		return
+	}
+	build.DownstreamPending = false
+}
+
+// createDownstreamBuilds creates the builds downstream of build which do not
+// exist yet. A BuildConfig whose build caused build, directly or through other
+// upstream builds, is not triggered again, so that a cycle of upstream relations
+// does not trigger builds forever.
+func (bc *BuildController) createDownstreamBuilds(build *api.Build) error {
+	if build.Config == nil {
+		return nil
+	}
+	configs, err := bc.osClient.ListBuildConfigs(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("error listing build configs: %v", err)
+	}
+	triggered, err := bc.downstreamConfigs(build)
+	if err != nil {
+		return err
+	}
+	chain, err := bc.upstreamChain(build)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for i := range configs.Items {
+		config := &configs.Items[i]
+		if !hasUpstream(config, build.Config.ID) || triggered[config.ID] {
+			continue
+		}
+		if chain[config.ID] {
+			glog.Warningf("Not triggering build config %s downstream of build %s, which was caused by a build of it", config.ID, build.ID)
+			continue
+		}
+		downstream, err := bc.createDownstreamBuild(config, build)
+		if err != nil {
+			lastErr = fmt.Errorf("error creating a build of build config %s: %v", config.ID, err)
+			glog.Errorf("Error creating a build of build config %s downstream of build %s: %v", config.ID, build.ID, err)
+			continue
+		}
+		glog.Infof("Created build %s, upstream build %s of build config %s completed", downstream.ID, build.ID, build.Config.ID)
+	}
+	return lastErr
+}
+
+// downstreamConfigs returns the ids of the BuildConfigs of which a build caused
+// by build already exists.
+func (bc *BuildController) downstreamConfigs(build *api.Build) (map[string]bool, error) {
+	builds, err := bc.osClient.ListBuilds(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("error listing builds: %v", err)
+	}
+	triggered := map[string]bool{}
+	for i := range builds.Items {
+		downstream := &builds.Items[i]
+		if cause := downstream.UpstreamBuildCause; cause != nil && cause.Build == build.ID && downstream.Config != nil {
+			triggered[downstream.Config.ID] = true
+		}
+	}
+	return triggered, nil
+}
+
+// upstreamChain returns the ids of the BuildConfig of build and of the
+// BuildConfigs of the upstream builds which caused it, following the
+// UpstreamBuildCause of every build until a build which was not caused by an
+// upstream build, or which no longer exists.
+func (bc *BuildController) upstreamChain(build *api.Build) (map[string]bool, error) {
+	chain := map[string]bool{build.Config.ID: true}
+	visited := map[string]bool{build.ID: true}
+	for cause := build.UpstreamBuildCause; cause != nil && !visited[cause.Build]; {
+		chain[cause.BuildConfig] = true
+		visited[cause.Build] = true
+		upstream, err := bc.osClient.GetBuild(cause.Build)
+		if isNotFound(err) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading upstream build %s: %v", cause.Build, err)
+		}
+		cause = upstream.UpstreamBuildCause
+	}
+	return chain, nil
+}
+
+// createDownstreamBuild advances the build counter of config and creates a build
+// linked to it which records the upstream build.
+func (bc *BuildController) createDownstreamBuild(config *api.BuildConfig, upstream *api.Build) (*api.Build, error) {
+	build, err := buildutil.LinkBuild(buildutil.NewClientConfigRegistry(bc.osClient), config.ID, func(config *api.BuildConfig, build *api.Build) error {
+		build.UpstreamBuildCause = &api.UpstreamBuildCause{
+			BuildConfig: upstream.Config.ID,
+			Build:       upstream.ID,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bc.osClient.CreateBuild(build)
+}
+
+// hasUpstream returns true if config lists the BuildConfig id as upstream.
+func hasUpstream(config *api.BuildConfig, id string) bool {
+	for _, upstream := range config.Upstream {
+		if upstream == id {
+			return true
+		}
+	}
+	return false
+}
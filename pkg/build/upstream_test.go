@@ -0,0 +1,251 @@
+package build
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// upstreamOsClient stores the updates of builds and build configs and the
+// created builds. The first updates of build configs are rejected with a
+// conflict, as if the build counter had been advanced concurrently, and the
+// first createErrs builds are not created.
+type upstreamOsClient struct {
+	watchOsClient
+	configs    []api.BuildConfig
+	conflicts  int
+	createErrs int
+	created    []api.Build
+}
+
+func (c *upstreamOsClient) ListBuilds(selector labels.Selector) (*api.BuildList, error) {
+	return &api.BuildList{Items: append(append([]api.Build{}, c.builds...), c.created...)}, nil
+}
+
+func (c *upstreamOsClient) GetBuild(id string) (*api.Build, error) {
+	for i := len(c.updated) - 1; i >= 0; i-- {
+		if c.updated[i].ID == id {
+			build := c.updated[i]
+			return &build, nil
+		}
+	}
+	builds, _ := c.ListBuilds(labels.Everything())
+	for i := range builds.Items {
+		if builds.Items[i].ID == id {
+			return &builds.Items[i], nil
+		}
+	}
+	return nil, kubeerrors.NewNotFound("build", id)
+}
+
+func (c *upstreamOsClient) ListBuildConfigs(selector labels.Selector) (*api.BuildConfigList, error) {
+	return &api.BuildConfigList{Items: append([]api.BuildConfig{}, c.configs...)}, nil
+}
+
+func (c *upstreamOsClient) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	for i := range c.configs {
+		if c.configs[i].ID == id {
+			config := c.configs[i]
+			return &config, nil
+		}
+	}
+	return nil, kubeerrors.NewNotFound("buildConfig", id)
+}
+
+func (c *upstreamOsClient) UpdateBuildConfig(config *api.BuildConfig) (*api.BuildConfig, error) {
+	for i := range c.configs {
+		if c.configs[i].ID != config.ID {
+			continue
+		}
+		if c.conflicts > 0 {
+			c.conflicts--
+			c.configs[i].LastVersion++
+			return nil, kubeerrors.NewConflict("buildConfig", config.ID, errors.New("modified"))
+		}
+		c.configs[i] = *config
+	}
+	return config, nil
+}
+
+func (c *upstreamOsClient) CreateBuild(build *api.Build) (*api.Build, error) {
+	if c.createErrs > 0 {
+		c.createErrs--
+		return nil, errors.New("etcd unavailable")
+	}
+	c.created = append(c.created, *build)
+	return build, nil
+}
+
+func mockUpstreamConfigs() []api.BuildConfig {
+	return []api.BuildConfig{
+		{JSONBase: kubeapi.JSONBase{ID: "dataBuild"}, LastVersion: 1},
+		{JSONBase: kubeapi.JSONBase{ID: "app"}, Upstream: []string{"library", "dataBuild"}, LastVersion: 3},
+		{JSONBase: kubeapi.JSONBase{ID: "other-app"}, Upstream: []string{"library"}},
+		{JSONBase: kubeapi.JSONBase{ID: "frontend"}, Upstream: []string{"app"}},
+	}
+}
+
+func TestSyncBuildTriggersDownstreamBuilds(t *testing.T) {
+	ctrl, build := setup()
+	client := &upstreamOsClient{configs: mockUpstreamConfigs()}
+	ctrl.osClient = client
+	ctrl.kubeClient = &okKubeClient{}
+	build.ID = "dataBuild-1"
+	build.Config = &api.BuildConfigReference{ID: "dataBuild", Version: 1}
+	build.Status = api.BuildRunning
+	build.StartTimestamp.Time = time.Now()
+
+	ctrl.syncBuild(build)
+
+	if len(client.created) != 1 {
+		t.Fatalf("Expected 1 downstream build, got %#v", client.created)
+	}
+	created := client.created[0]
+	if created.ID != "app-4" || created.Config == nil || created.Config.ID != "app" {
+		t.Errorf("Expected the build to be linked to the downstream config, got %#v", created)
+	}
+	cause := created.UpstreamBuildCause
+	if cause == nil || cause.BuildConfig != "dataBuild" || cause.Build != "dataBuild-1" {
+		t.Errorf("Expected the upstream build to be recorded, got %#v", cause)
+	}
+	if client.configs[1].LastVersion != 4 {
+		t.Errorf("Expected the build counter of the downstream config to be stored, got %d", client.configs[1].LastVersion)
+	}
+	if len(client.updated) != 2 || !client.updated[0].DownstreamPending || client.updated[1].DownstreamPending {
+		t.Errorf("Expected the build to be stored with pending downstream builds, then without, got %#v", client.updated)
+	}
+
+	// the build is complete, synchronizing it again triggers nothing
+	ctrl.syncBuild(&client.updated[0])
+	if len(client.created) != 1 {
+		t.Errorf("Expected no further downstream build, got %d", len(client.created)-1)
+	}
+}
+
+func TestSyncBuildFailedTriggersNoDownstreamBuilds(t *testing.T) {
+	ctrl, build := setup()
+	client := &upstreamOsClient{configs: mockUpstreamConfigs()}
+	ctrl.osClient = client
+	ctrl.kubeClient = &failedContainerKubeClient{}
+	build.Config = &api.BuildConfigReference{ID: "dataBuild", Version: 1}
+	build.Status = api.BuildRunning
+
+	ctrl.syncBuild(build)
+
+	if len(client.updated) != 1 || client.updated[0].Status == api.BuildComplete {
+		t.Fatalf("Expected the build not to complete, got %#v", client.updated)
+	}
+	if len(client.created) != 0 {
+		t.Errorf("Expected no downstream build, got %#v", client.created)
+	}
+}
+
+func TestTriggerDownstreamBuildsConflict(t *testing.T) {
+	ctrl, build := setup()
+	client := &upstreamOsClient{configs: mockUpstreamConfigs(), conflicts: 1}
+	ctrl.osClient = client
+	build.ID = "dataBuild-1"
+	build.Config = &api.BuildConfigReference{ID: "dataBuild", Version: 1}
+	build.DownstreamPending = true
+
+	ctrl.triggerDownstreamBuilds(build)
+
+	if len(client.created) != 1 {
+		t.Fatalf("Expected 1 downstream build, got %#v", client.created)
+	}
+	if id := client.created[0].ID; id != "app-5" {
+		t.Errorf("Expected the build to be numbered after the concurrent build, got %s", id)
+	}
+}
+
+func TestTriggerDownstreamBuildsWithoutConfig(t *testing.T) {
+	ctrl, build := setup()
+	client := &upstreamOsClient{configs: mockUpstreamConfigs()}
+	ctrl.osClient = client
+	build.DownstreamPending = true
+
+	ctrl.triggerDownstreamBuilds(build)
+
+	if len(client.created) != 0 {
+		t.Errorf("Expected no downstream build, got %#v", client.created)
+	}
+}
+
+func TestSynchronizeAllRetriesDownstreamBuilds(t *testing.T) {
+	ctrl, build := setup()
+	configs := append(mockUpstreamConfigs(), api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "docs"}, Upstream: []string{"dataBuild"}})
+	client := &upstreamOsClient{configs: configs, createErrs: 1}
+	ctrl.osClient = client
+	build.ID = "dataBuild-1"
+	build.Config = &api.BuildConfigReference{ID: "dataBuild", Version: 1}
+	build.Status = api.BuildComplete
+	build.DownstreamPending = true
+	client.builds = []api.Build{*build}
+
+	ctrl.synchronizeAll()
+
+	if len(client.created) != 1 || client.created[0].Config.ID != "docs" {
+		t.Fatalf("Expected the build of docs to be created, got %#v", client.created)
+	}
+	if current, _ := client.GetBuild(build.ID); !current.DownstreamPending {
+		t.Fatalf("Expected the downstream builds to stay pending")
+	}
+
+	ctrl.synchronizeAll()
+
+	if len(client.created) != 2 || client.created[1].Config.ID != "app" {
+		t.Fatalf("Expected only the build of app to be created, got %#v", client.created)
+	}
+	current, _ := client.GetBuild(build.ID)
+	if current.DownstreamPending {
+		t.Fatalf("Expected the downstream builds to be recorded as triggered")
+	}
+	client.builds = []api.Build{*current}
+
+	ctrl.synchronizeAll()
+
+	if len(client.created) != 2 {
+		t.Errorf("Expected no further downstream build, got %#v", client.created[2:])
+	}
+}
+
+func TestTriggerDownstreamBuildsStopsAtCycle(t *testing.T) {
+	ctrl, build := setup()
+	configs := mockUpstreamConfigs()
+	// the upstream relations were validated one at a time, but form a cycle
+	configs[0].Upstream = []string{"frontend"}
+	client := &upstreamOsClient{configs: configs}
+	client.builds = []api.Build{
+		{
+			JSONBase: kubeapi.JSONBase{ID: "dataBuild-1"},
+			Config:   &api.BuildConfigReference{ID: "dataBuild", Version: 1},
+			Status:   api.BuildComplete,
+		},
+		{
+			JSONBase:           kubeapi.JSONBase{ID: "app-4"},
+			Config:             &api.BuildConfigReference{ID: "app", Version: 4},
+			Status:             api.BuildComplete,
+			UpstreamBuildCause: &api.UpstreamBuildCause{BuildConfig: "dataBuild", Build: "dataBuild-1"},
+		},
+	}
+	ctrl.osClient = client
+	build.ID = "frontend-1"
+	build.Config = &api.BuildConfigReference{ID: "frontend", Version: 1}
+	build.UpstreamBuildCause = &api.UpstreamBuildCause{BuildConfig: "app", Build: "app-4"}
+	build.DownstreamPending = true
+	client.builds = append(client.builds, *build)
+
+	ctrl.triggerDownstreamBuilds(build)
+
+	if len(client.created) != 0 {
+		t.Errorf("Expected the cycle not to trigger dataBuild again, got %#v", client.created)
+	}
+	if build.DownstreamPending {
+		t.Errorf("Expected the downstream builds to be recorded as triggered")
+	}
+}
@@ -0,0 +1,3 @@
+// Package util contains helpers shared by the build controller, the build
+// storage and the build related API handlers.
+package util
@@ -0,0 +1,91 @@
+package util
+
+import (
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// MaxConflictRetries is the number of times an object which was modified
+// concurrently, such as the build counter of a BuildConfig or the tags of an
+// ImageRepository, is read and modified again.
+const MaxConflictRetries = 3
+
+// ConfigRegistry reads and advances the build counters of BuildConfigs.
+type ConfigRegistry interface {
+	GetBuildConfig(id string) (*api.BuildConfig, error)
+	UpdateBuildConfig(config *api.BuildConfig) error
+}
+
+// ConfigClient reads and updates BuildConfigs through the OpenShift client.
+type ConfigClient interface {
+	GetBuildConfig(id string) (*api.BuildConfig, error)
+	UpdateBuildConfig(config *api.BuildConfig) (*api.BuildConfig, error)
+}
+
+// NewClientConfigRegistry returns a ConfigRegistry storing BuildConfigs
+// through client.
+func NewClientConfigRegistry(client ConfigClient) ConfigRegistry {
+	return clientConfigRegistry{client}
+}
+
+type clientConfigRegistry struct {
+	client ConfigClient
+}
+
+func (r clientConfigRegistry) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	return r.client.GetBuildConfig(id)
+}
+
+func (r clientConfigRegistry) UpdateBuildConfig(config *api.BuildConfig) error {
+	_, err := r.client.UpdateBuildConfig(config)
+	return err
+}
+
+// LinkBuild advances the build counter of the BuildConfig identified by id and
+// returns a new build linked to it, which the caller is responsible for
+// creating. The build receives the DesiredInput of the BuildConfig, prepare may
+// modify it before the BuildConfig is stored and reject it by returning an
+// error. A BuildConfig modified concurrently is read again and prepare is called
+// with the new version.
+func LinkBuild(configs ConfigRegistry, id string, prepare func(config *api.BuildConfig, build *api.Build) error) (*api.Build, error) {
+	for retries := 0; ; retries++ {
+		config, err := configs.GetBuildConfig(id)
+		if err != nil {
+			return nil, err
+		}
+
+		build := &api.Build{
+			Input:  config.DesiredInput,
+			Status: api.BuildNew,
+		}
+		build.CreationTimestamp = util.Now()
+		LinkBuildToConfig(build, config)
+		if prepare != nil {
+			if err := prepare(config, build); err != nil {
+				return nil, err
+			}
+		}
+
+		err = configs.UpdateBuildConfig(config)
+		if IsConflict(err) && retries < MaxConflictRetries {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return build, nil
+	}
+}
+
+// IsConflict returns true if err indicates that the object being updated was
+// modified concurrently, whether the error was returned by a client or by a
+// registry.
+func IsConflict(err error) bool {
+	if statusErr, ok := err.(*kubeclient.StatusErr); ok {
+		return statusErr.Status.Reason == kubeapi.StatusReasonConflict
+	}
+	return errors.IsConflict(err)
+}
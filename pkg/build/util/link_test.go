@@ -0,0 +1,93 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeerrors "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// conflictingConfigs advances the build counter concurrently, rejecting the
+// first conflicts updates.
+type conflictingConfigs struct {
+	config    api.BuildConfig
+	conflicts int
+	updates   int
+}
+
+func (c *conflictingConfigs) GetBuildConfig(id string) (*api.BuildConfig, error) {
+	config := c.config
+	return &config, nil
+}
+
+func (c *conflictingConfigs) UpdateBuildConfig(config *api.BuildConfig) error {
+	c.updates++
+	if c.conflicts > 0 {
+		c.conflicts--
+		c.config.LastVersion++
+		return kubeerrors.NewConflict("buildConfig", config.ID, errors.New("modified"))
+	}
+	c.config = *config
+	return nil
+}
+
+func TestLinkBuildRetriesConflicts(t *testing.T) {
+	configs := &conflictingConfigs{
+		config:    api.BuildConfig{JSONBase: kubeapi.JSONBase{ID: "myapp"}, LastVersion: 3},
+		conflicts: 1,
+	}
+	prepared := 0
+	build, err := LinkBuild(configs, "myapp", func(config *api.BuildConfig, build *api.Build) error {
+		prepared++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if build.ID != "myapp-5" || build.Status != api.BuildNew {
+		t.Errorf("Expected the new build myapp-5 linked to the current version, got %#v", build)
+	}
+	if prepared != 2 || configs.updates != 2 {
+		t.Errorf("Expected 2 attempts, got %d preparations and %d updates", prepared, configs.updates)
+	}
+	if configs.config.LastVersion != 5 {
+		t.Errorf("Expected the build counter 5 to be stored, got %d", configs.config.LastVersion)
+	}
+}
+
+func TestLinkBuildGivesUpAfterConflicts(t *testing.T) {
+	configs := &conflictingConfigs{conflicts: MaxConflictRetries + 1}
+	if _, err := LinkBuild(configs, "myapp", nil); !IsConflict(err) {
+		t.Errorf("Expected a conflict, got %v", err)
+	}
+	if configs.updates != MaxConflictRetries+1 {
+		t.Errorf("Expected %d updates, got %d", MaxConflictRetries+1, configs.updates)
+	}
+}
+
+func TestLinkBuildRejected(t *testing.T) {
+	configs := &conflictingConfigs{}
+	rejected := errors.New("rejected")
+	_, err := LinkBuild(configs, "myapp", func(config *api.BuildConfig, build *api.Build) error {
+		return rejected
+	})
+	if err != rejected || configs.updates != 0 {
+		t.Errorf("Expected the build config not to be updated, got %v after %d updates", err, configs.updates)
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	clientErr := &kubeclient.StatusErr{Status: kubeapi.Status{Reason: kubeapi.StatusReasonConflict}}
+	if !IsConflict(clientErr) {
+		t.Errorf("Expected a client conflict to be detected")
+	}
+	if !IsConflict(kubeerrors.NewConflict("buildConfig", "myapp", errors.New("modified"))) {
+		t.Errorf("Expected a registry conflict to be detected")
+	}
+	if IsConflict(errors.New("unreachable")) || IsConflict(nil) {
+		t.Errorf("Unexpected conflict")
+	}
+}
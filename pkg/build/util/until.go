@@ -0,0 +1,29 @@
+package util
+
+import (
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Until runs f every period until stop is closed, recovering from panics as
+// util.Forever does. It returns once stop is closed, after the current run of
+// f has returned.
+func Until(f func(), period time.Duration, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		func() {
+			defer util.HandleCrash()
+			f()
+		}()
+		select {
+		case <-stop:
+			return
+		case <-time.After(period):
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUntilStops(t *testing.T) {
+	stop := make(chan struct{})
+	runs := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		Until(func() { runs <- struct{}{} }, time.Millisecond, stop)
+		close(done)
+	}()
+	<-runs
+	<-runs
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Until to return once stopped")
+	}
+}
+
+func TestUntilStopped(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+	Until(func() { t.Errorf("Unexpected run after stop") }, time.Millisecond, stop)
+}
+
+func TestUntilRecoversFromPanic(t *testing.T) {
+	stop := make(chan struct{})
+	runs := 0
+	Until(func() {
+		runs++
+		if runs == 2 {
+			close(stop)
+		}
+		panic("test")
+	}, time.Millisecond, stop)
+	if runs != 2 {
+		t.Errorf("Expected f to run again after a panic, got %d runs", runs)
+	}
+}
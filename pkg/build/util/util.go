@@ -0,0 +1,112 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// IsBuildComplete returns true if the build has reached a terminal status
+// and will never transition to another status.
+func IsBuildComplete(build *api.Build) bool {
+	for _, status := range terminalStatuses {
+		if build.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// terminalStatuses are the statuses from which a build never transitions.
+var terminalStatuses = []api.BuildStatus{api.BuildComplete, api.BuildFailed, api.BuildError, api.BuildCancelled}
+
+// SetStatusLabels sets the labels reflecting the status of the build, so that
+// builds can be listed by status: api.BuildStatusLabel is set to the status and
+// api.DownstreamPendingLabel is only set while DownstreamPending is.
+func SetStatusLabels(build *api.Build) {
+	if build.Labels == nil {
+		build.Labels = make(map[string]string)
+	}
+	build.Labels[api.BuildStatusLabel] = string(build.Status)
+	if build.DownstreamPending {
+		build.Labels[api.DownstreamPendingLabel] = "true"
+	} else {
+		delete(build.Labels, api.DownstreamPendingLabel)
+	}
+}
+
+// ActiveBuildsSelector selects the builds which have not reached a terminal
+// status. Builds stored without status labels are selected too.
+func ActiveBuildsSelector() labels.Selector {
+	requirements := []string{}
+	for _, status := range terminalStatuses {
+		requirements = append(requirements, api.BuildStatusLabel+"!="+string(status))
+	}
+	return mustParseSelector(strings.Join(requirements, ","))
+}
+
+// StatusSelector selects the builds in the given status.
+func StatusSelector(status api.BuildStatus) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{api.BuildStatusLabel: string(status)})
+}
+
+// DownstreamPendingSelector selects the builds whose downstream builds have not
+// been triggered yet.
+func DownstreamPendingSelector() labels.Selector {
+	return labels.SelectorFromSet(labels.Set{api.DownstreamPendingLabel: "true"})
+}
+
+func mustParseSelector(selector string) labels.Selector {
+	parsed, err := labels.ParseSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// LinkBuildToConfig advances the build counter of the given BuildConfig and
+// names the build after it (eg. myapp-17). The build inherits the labels of the
+// configuration, is labeled with api.BuildConfigLabel and records a reference
+// to the configuration. The caller is responsible for persisting the updated
+// BuildConfig.
+func LinkBuildToConfig(build *api.Build, config *api.BuildConfig) {
+	config.LastVersion++
+	build.ID = fmt.Sprintf("%s-%d", config.ID, config.LastVersion)
+	build.Config = &api.BuildConfigReference{
+		ID:      config.ID,
+		Version: config.LastVersion,
+	}
+	if build.Labels == nil {
+		build.Labels = make(map[string]string)
+	}
+	for key, value := range config.Labels {
+		if _, ok := build.Labels[key]; !ok {
+			build.Labels[key] = value
+		}
+	}
+	build.Labels[api.BuildConfigLabel] = config.ID
+}
+
+// MergeEnv returns env with the variables of overrides applied: a variable of
+// overrides replaces the variable of env with the same name, other variables
+// are appended. Neither env nor overrides is modified.
+func MergeEnv(env, overrides []kubeapi.EnvVar) []kubeapi.EnvVar {
+	result := make([]kubeapi.EnvVar, len(env), len(env)+len(overrides))
+	copy(result, env)
+	index := make(map[string]int, len(result))
+	for i, v := range result {
+		index[v.Name] = i
+	}
+	for _, v := range overrides {
+		if i, ok := index[v.Name]; ok {
+			result[i] = v
+			continue
+		}
+		index[v.Name] = len(result)
+		result = append(result, v)
+	}
+	return result
+}
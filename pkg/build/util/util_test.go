@@ -0,0 +1,118 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+func TestIsBuildComplete(t *testing.T) {
+	expected := map[api.BuildStatus]bool{
+		api.BuildNew:       false,
+		api.BuildPending:   false,
+		api.BuildRunning:   false,
+		api.BuildComplete:  true,
+		api.BuildFailed:    true,
+		api.BuildError:     true,
+		api.BuildCancelled: true,
+	}
+	for status, complete := range expected {
+		if e, a := complete, IsBuildComplete(&api.Build{Status: status}); e != a {
+			t.Errorf("%s: expected %t, got %t", status, e, a)
+		}
+	}
+}
+
+func TestSetStatusLabels(t *testing.T) {
+	build := &api.Build{Status: api.BuildComplete, DownstreamPending: true}
+	SetStatusLabels(build)
+	if !DownstreamPendingSelector().Matches(labels.Set(build.Labels)) || ActiveBuildsSelector().Matches(labels.Set(build.Labels)) {
+		t.Errorf("Expected a complete build with pending downstream builds, got labels %v", build.Labels)
+	}
+
+	build.DownstreamPending = false
+	SetStatusLabels(build)
+	if DownstreamPendingSelector().Matches(labels.Set(build.Labels)) {
+		t.Errorf("Expected the downstream pending label to be removed, got labels %v", build.Labels)
+	}
+
+	build.Status = api.BuildRunning
+	SetStatusLabels(build)
+	if !ActiveBuildsSelector().Matches(labels.Set(build.Labels)) || !StatusSelector(api.BuildRunning).Matches(labels.Set(build.Labels)) {
+		t.Errorf("Expected a running build, got labels %v", build.Labels)
+	}
+}
+
+func TestActiveBuildsSelector(t *testing.T) {
+	if !ActiveBuildsSelector().Matches(labels.Set{"name": "myapp"}) {
+		t.Errorf("Expected a build stored without status labels to be selected")
+	}
+	for _, status := range []api.BuildStatus{api.BuildNew, api.BuildQueued, api.BuildPending, api.BuildRunning} {
+		if !ActiveBuildsSelector().Matches(labels.Set{api.BuildStatusLabel: string(status)}) {
+			t.Errorf("Expected a build in status %s to be selected", status)
+		}
+	}
+	for _, status := range terminalStatuses {
+		if ActiveBuildsSelector().Matches(labels.Set{api.BuildStatusLabel: string(status)}) {
+			t.Errorf("Expected a build in status %s not to be selected", status)
+		}
+	}
+}
+
+func TestLinkBuildToConfig(t *testing.T) {
+	config := &api.BuildConfig{
+		JSONBase:    kubeapi.JSONBase{ID: "myapp"},
+		Labels:      map[string]string{"name": "myapp", "tier": "frontend"},
+		LastVersion: 16,
+	}
+	build := &api.Build{
+		Labels: map[string]string{"tier": "backend"},
+	}
+	LinkBuildToConfig(build, config)
+
+	if e, a := 17, config.LastVersion; e != a {
+		t.Errorf("Expected config version %d, got %d", e, a)
+	}
+	if e, a := "myapp-17", build.ID; e != a {
+		t.Errorf("Expected build id %s, got %s", e, a)
+	}
+	if build.Config == nil || build.Config.ID != "myapp" || build.Config.Version != 17 {
+		t.Errorf("Unexpected config reference: %#v", build.Config)
+	}
+	expected := map[string]string{
+		"name":               "myapp",
+		"tier":               "backend",
+		api.BuildConfigLabel: "myapp",
+	}
+	if !reflect.DeepEqual(expected, build.Labels) {
+		t.Errorf("Expected labels %v, got %v", expected, build.Labels)
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := []kubeapi.EnvVar{
+		{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"},
+		{Name: "FEATURE_X", Value: "off"},
+	}
+	overrides := []kubeapi.EnvVar{
+		{Name: "FEATURE_X", Value: "on"},
+		{Name: "FEATURE_Y", Value: "on"},
+	}
+
+	result := MergeEnv(env, overrides)
+
+	expected := []kubeapi.EnvVar{
+		{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"},
+		{Name: "FEATURE_X", Value: "on"},
+		{Name: "FEATURE_Y", Value: "on"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+	if env[1].Value != "off" || len(env) != 2 {
+		t.Errorf("Expected the original environment to be unchanged, got %v", env)
+	}
+}
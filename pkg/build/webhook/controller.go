@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/openshift/origin/pkg/build/api"
+	buildutil "github.com/openshift/origin/pkg/build/util"
 	"github.com/openshift/origin/pkg/client"
 )
 
@@ -40,39 +41,54 @@ func NewController(osClient client.Interface, plugins map[string]Plugin) http.Ha
 func (c *controller) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	uv, err := parseUrl(req.URL.Path)
 	if err != nil {
+		c.countDelivery(uv.plugin, deliveryNotFound)
 		notFound(w, err.Error())
 		return
 	}
 
 	buildCfg, err := c.osClient.GetBuildConfig(uv.buildId)
 	if err != nil {
+		c.countDelivery(uv.plugin, deliveryInvalid)
 		badRequest(w, err.Error())
 		return
 	}
 	if uv.secret != buildCfg.Secret {
+		c.countDelivery(uv.plugin, deliveryForbidden)
 		badRequest(w, "")
 		return
 	}
 
 	plugin, ok := c.plugins[uv.plugin]
 	if !ok {
+		c.countDelivery(uv.plugin, deliveryNotFound)
 		notFound(w, "Plugin ", uv.plugin, " not found!")
 		return
 	}
 	build, err := plugin.Extract(buildCfg, uv.path, req)
 	if err != nil {
+		c.countDelivery(uv.plugin, deliveryInvalid)
 		badRequest(w, err.Error())
 		return
 	}
-	if build == nil {
-		build = &api.Build{
-			Input: buildCfg.DesiredInput,
+	extracted := build
+	build, err = buildutil.LinkBuild(buildutil.NewClientConfigRegistry(c.osClient), uv.buildId, func(config *api.BuildConfig, build *api.Build) error {
+		if extracted != nil {
+			build.Input = extracted.Input
 		}
+		return nil
+	})
+	if err != nil {
+		c.countDelivery(uv.plugin, deliveryError)
+		badRequest(w, err.Error())
+		return
 	}
 
 	if _, err := c.osClient.CreateBuild(build); err != nil {
+		c.countDelivery(uv.plugin, deliveryError)
 		badRequest(w, err.Error())
+		return
 	}
+	c.countDelivery(uv.plugin, deliveryCreated)
 }
 
 func parseUrl(url string) (uv urlVars, err error) {
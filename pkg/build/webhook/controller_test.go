@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/openshift/origin/pkg/build/api"
 	"github.com/openshift/origin/pkg/client"
 )
@@ -213,3 +215,135 @@ func TestInvokeWebhookOk(t *testing.T) {
 			string(body))
 	}
 }
+
+type recordingClient struct {
+	osClient
+	updatedConfig *api.BuildConfig
+	createdBuild  *api.Build
+}
+
+func (_ *recordingClient) GetBuildConfig(id string) (result *api.BuildConfig, err error) {
+	return &api.BuildConfig{
+		JSONBase: kubeapi.JSONBase{ID: id},
+		Labels:   map[string]string{"name": "myapp"},
+		DesiredInput: api.BuildInput{
+			Source: api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.build.com/the/build/Dockerfile"}},
+			Env:    []kubeapi.EnvVar{{Name: "MAVEN_MIRROR_URL", Value: "http://mirror.example.com"}},
+		},
+		Secret:      "secret101",
+		LastVersion: 16,
+	}, nil
+}
+
+func (c *recordingClient) UpdateBuildConfig(config *api.BuildConfig) (*api.BuildConfig, error) {
+	c.updatedConfig = config
+	return config, nil
+}
+
+func (c *recordingClient) CreateBuild(build *api.Build) (*api.Build, error) {
+	c.createdBuild = build
+	return build, nil
+}
+
+func TestInvokeWebhookLinksBuildToConfig(t *testing.T) {
+	osClient := &recordingClient{}
+	server := httptest.NewServer(NewController(osClient, map[string]Plugin{
+		"okPlugin": &pathPlugin{},
+	}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/myapp/secret101/okPlugin",
+		"application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong response code, expecting 200, got %s: %s!", resp.Status,
+			string(body))
+	}
+	if osClient.updatedConfig == nil || osClient.updatedConfig.LastVersion != 17 {
+		t.Errorf("Expected the build counter to be advanced and saved, got %#v", osClient.updatedConfig)
+	}
+	build := osClient.createdBuild
+	if build == nil {
+		t.Fatalf("Expected a build to be created")
+	}
+	if build.ID != "myapp-17" {
+		t.Errorf("Expected build id myapp-17, got %s", build.ID)
+	}
+	if build.Config == nil || build.Config.ID != "myapp" {
+		t.Errorf("Expected a reference to config myapp, got %#v", build.Config)
+	}
+	if build.Labels["name"] != "myapp" || build.Labels[api.BuildConfigLabel] != "myapp" {
+		t.Errorf("Expected labels to be inherited from the config, got %v", build.Labels)
+	}
+	if build.Input.Source.Git.URI != "http://my.build.com/the/build/Dockerfile" {
+		t.Errorf("Expected the desired input of the config, got %#v", build.Input)
+	}
+	if len(build.Input.Env) != 1 || build.Input.Env[0].Name != "MAVEN_MIRROR_URL" {
+		t.Errorf("Expected the environment of the config, got %v", build.Input.Env)
+	}
+}
+
+func TestInvokeWebhookCountsDeliveries(t *testing.T) {
+	server := httptest.NewServer(NewController(&osClient{}, map[string]Plugin{
+		"okPlugin":  &pathPlugin{},
+		"errPlugin": &errPlugin{},
+	}))
+	defer server.Close()
+
+	created := webhookDeliveries.Value("okPlugin", deliveryCreated)
+	invalid := webhookDeliveries.Value("errPlugin", deliveryInvalid)
+	notFound := webhookDeliveries.Value("unknown", deliveryNotFound)
+	for _, path := range []string{"/build100/secret101/okPlugin", "/build100/secret101/errPlugin", "/build100/secret101/missing"} {
+		if _, err := http.Post(server.URL+path, "application/json", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if value := webhookDeliveries.Value("okPlugin", deliveryCreated); value != created+1 {
+		t.Errorf("Expected a created delivery to be counted, got %v", value-created)
+	}
+	if value := webhookDeliveries.Value("errPlugin", deliveryInvalid); value != invalid+1 {
+		t.Errorf("Expected an invalid delivery to be counted, got %v", value-invalid)
+	}
+	if value := webhookDeliveries.Value("unknown", deliveryNotFound); value != notFound+1 {
+		t.Errorf("Expected a delivery to an unknown plugin to be counted, got %v", value-notFound)
+	}
+}
+
+// conflictingClient rejects the first update of the build config, as if the
+// build counter had been advanced concurrently.
+type conflictingClient struct {
+	recordingClient
+	conflicts int
+}
+
+func (c *conflictingClient) UpdateBuildConfig(config *api.BuildConfig) (*api.BuildConfig, error) {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return nil, &kubeclient.StatusErr{Status: kubeapi.Status{Reason: kubeapi.StatusReasonConflict}}
+	}
+	return c.recordingClient.UpdateBuildConfig(config)
+}
+
+func TestInvokeWebhookRetriesConflicts(t *testing.T) {
+	osClient := &conflictingClient{conflicts: 1}
+	server := httptest.NewServer(NewController(osClient, map[string]Plugin{
+		"okPlugin": &pathPlugin{},
+	}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/myapp/secret101/okPlugin",
+		"application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong response code, expecting 200, got %s!", resp.Status)
+	}
+	if osClient.updatedConfig == nil || osClient.createdBuild == nil || osClient.createdBuild.ID != "myapp-17" {
+		t.Errorf("Expected the build to be created after the conflict, got %#v", osClient.createdBuild)
+	}
+}
@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"github.com/openshift/origin/pkg/metrics"
+)
+
+// Results of webhook deliveries
+const (
+	deliveryCreated   = "created"
+	deliveryNotFound  = "not_found"
+	deliveryForbidden = "forbidden"
+	deliveryInvalid   = "invalid"
+	deliveryError     = "error"
+)
+
+var webhookDeliveries = metrics.NewCounter("openshift_webhook_deliveries_total",
+	"Number of webhook deliveries, by plugin and result.", "plugin", "result")
+
+func init() {
+	metrics.DefaultRegistry.Register(webhookDeliveries)
+}
+
+// countDelivery records the result of a webhook delivery. Deliveries to plugins
+// which do not exist are counted together, so the URL can't add new label values.
+func (c *controller) countDelivery(plugin, result string) {
+	if _, ok := c.plugins[plugin]; !ok {
+		plugin = "unknown"
+	}
+	webhookDeliveries.Inc(plugin, result)
+}
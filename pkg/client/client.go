@@ -1,6 +1,11 @@
 package client
 
 import (
+	"io"
+	"net/url"
+	"path"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
@@ -22,9 +27,15 @@ type Interface interface {
 // BuildInterface exposes methods on Build resources.
 type BuildInterface interface {
 	ListBuilds(labels.Selector) (*buildapi.BuildList, error)
+	GetBuild(string) (*buildapi.Build, error)
+	WatchBuilds(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error)
 	CreateBuild(*buildapi.Build) (*buildapi.Build, error)
 	UpdateBuild(*buildapi.Build) (*buildapi.Build, error)
 	DeleteBuild(string) error
+	BuildLogs(id string, follow bool) (io.ReadCloser, error)
+	CancelBuild(string) (*buildapi.Build, error)
+	CloneBuild(string) (*buildapi.Build, error)
+	RenderBuildPod(*buildapi.Build) (*kubeapi.Pod, error)
 }
 
 // BuildConfigInterface exposes methods on BuildConfig resources
@@ -34,6 +45,9 @@ type BuildConfigInterface interface {
 	CreateBuildConfig(*buildapi.BuildConfig) (*buildapi.BuildConfig, error)
 	UpdateBuildConfig(*buildapi.BuildConfig) (*buildapi.BuildConfig, error)
 	DeleteBuildConfig(string) error
+	InstantiateBuildConfig(string, *buildapi.BuildRequest) (*buildapi.Build, error)
+	UploadBuildConfigArchive(string, io.Reader) (*buildapi.Build, error)
+	RenderBuildConfigPod(*buildapi.BuildConfig) (*kubeapi.Pod, error)
 }
 
 // ImageInterface exposes methods on Image resources.
@@ -60,6 +74,9 @@ type ImageRepositoryMappingInterface interface {
 // Client is an OpenShift client object
 type Client struct {
 	*kubeclient.RESTClient
+	// streams performs the requests whose responses are streamed, which the
+	// RESTClient does not support
+	streams *streamClient
 }
 
 // New creates and returns a new Client.
@@ -68,7 +85,11 @@ func New(host string, auth *kubeclient.AuthInfo) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Client{restClient}, nil
+	streams, err := newStreamClient(host, auth, "/osapi/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	return &Client{restClient, streams}, nil
 }
 
 // CreateBuild creates new build. Returns the server's representation of the build and error if one occurs.
@@ -85,6 +106,24 @@ func (c *Client) ListBuilds(selector labels.Selector) (result *buildapi.BuildLis
 	return
 }
 
+// GetBuild returns information about a particular build and error if one occurs.
+func (c *Client) GetBuild(id string) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	err = c.Get().Path("builds").Path(id).Do().Into(result)
+	return
+}
+
+// WatchBuilds returns a watch.Interface that watches the requested builds.
+func (c *Client) WatchBuilds(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.Get().
+		Path("watch").
+		Path("builds").
+		UintParam("resourceVersion", resourceVersion).
+		SelectorParam("labels", label).
+		SelectorParam("fields", field).
+		Watch()
+}
+
 // UpdateBuild updates the build on server. Returns the server's representation of the build and error if one occurs.
 func (c *Client) UpdateBuild(build *buildapi.Build) (result *buildapi.Build, err error) {
 	result = &buildapi.Build{}
@@ -98,6 +137,36 @@ func (c *Client) DeleteBuild(id string) (err error) {
 	return
 }
 
+// BuildLogs returns the log of the builder of a build. When follow is true, the log is streamed until the build reaches a terminal status. The caller must close the returned log.
+func (c *Client) BuildLogs(id string, follow bool) (io.ReadCloser, error) {
+	query := url.Values{}
+	if follow {
+		query.Set("follow", "true")
+	}
+	return c.streams.Get(path.Join("builds", id, "log"), query)
+}
+
+// CancelBuild requests cancellation of a build. Returns the server's representation of the build and error if one occurs.
+func (c *Client) CancelBuild(id string) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	err = c.Post().Path("builds").Path(id).Path("cancel").Do().Into(result)
+	return
+}
+
+// CloneBuild creates a new build with the input and revision of a build. Returns the created build and error if one occurs.
+func (c *Client) CloneBuild(id string) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	err = c.Post().Path("builds").Path(id).Path("clone").Do().Into(result)
+	return
+}
+
+// RenderBuildPod returns the pod which would execute a build, without creating it. The credentials of the build are redacted. Returns the pod and error if one occurs.
+func (c *Client) RenderBuildPod(build *buildapi.Build) (result *kubeapi.Pod, err error) {
+	result = &kubeapi.Pod{}
+	err = c.Post().Path("renderBuildPod").Body(build).Do().Into(result)
+	return
+}
+
 // CreateBuildConfig creates a new buildconfig. Returns the server's representation of the buildconfig and error if one occurs.
 func (c *Client) CreateBuildConfig(build *buildapi.BuildConfig) (result *buildapi.BuildConfig, err error) {
 	result = &buildapi.BuildConfig{}
@@ -131,6 +200,27 @@ func (c *Client) DeleteBuildConfig(id string) error {
 	return c.Delete().Path("buildConfigs").Path(id).Do().Error()
 }
 
+// InstantiateBuildConfig creates a build from a BuildConfig, applying the overrides of request. Returns the created build and error if one occurs.
+func (c *Client) InstantiateBuildConfig(id string, request *buildapi.BuildRequest) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	err = c.Post().Path("buildConfigs").Path(id).Path("instantiate").Body(request).Do().Into(result)
+	return
+}
+
+// UploadBuildConfigArchive creates a build of a tar or zip archive from a BuildConfig, the archive is streamed to the server. Returns the created build and error if one occurs.
+func (c *Client) UploadBuildConfigArchive(id string, archive io.Reader) (result *buildapi.Build, err error) {
+	result = &buildapi.Build{}
+	err = c.Post().Path("buildConfigs").Path(id).Path("upload").Body(archive).Do().Into(result)
+	return
+}
+
+// RenderBuildConfigPod returns the pod which would execute the next build of a BuildConfig, without creating it. The credentials of the build are redacted. Returns the pod and error if one occurs.
+func (c *Client) RenderBuildConfigPod(config *buildapi.BuildConfig) (result *kubeapi.Pod, err error) {
+	result = &kubeapi.Pod{}
+	err = c.Post().Path("renderBuildPod").Body(config).Do().Into(result)
+	return
+}
+
 // ListImages returns a list of images that match the selector.
 func (c *Client) ListImages(selector labels.Selector) (result *imageapi.ImageList, err error) {
 	result = &imageapi.ImageList{}
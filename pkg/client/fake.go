@@ -1,6 +1,11 @@
 package client
 
 import (
+	"io"
+	"io/ioutil"
+	"strings"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	buildapi "github.com/openshift/origin/pkg/build/api"
@@ -29,6 +34,16 @@ func (c *Fake) ListBuilds(selector labels.Selector) (*buildapi.BuildList, error)
 	return &buildapi.BuildList{}, nil
 }
 
+func (c *Fake) GetBuild(id string) (*buildapi.Build, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "get-build", Value: id})
+	return &buildapi.Build{}, nil
+}
+
+func (c *Fake) WatchBuilds(field, label labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "watch-builds"})
+	return nil, nil
+}
+
 func (c *Fake) UpdateBuild(build *buildapi.Build) (*buildapi.Build, error) {
 	c.Actions = append(c.Actions, FakeAction{Action: "update-build"})
 	return &buildapi.Build{}, nil
@@ -39,6 +54,26 @@ func (c *Fake) DeleteBuild(id string) error {
 	return nil
 }
 
+func (c *Fake) BuildLogs(id string, follow bool) (io.ReadCloser, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "get-build-logs", Value: id})
+	return ioutil.NopCloser(strings.NewReader("")), nil
+}
+
+func (c *Fake) CancelBuild(id string) (*buildapi.Build, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "cancel-build", Value: id})
+	return &buildapi.Build{}, nil
+}
+
+func (c *Fake) CloneBuild(id string) (*buildapi.Build, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "clone-build", Value: id})
+	return &buildapi.Build{}, nil
+}
+
+func (c *Fake) RenderBuildPod(build *buildapi.Build) (*kubeapi.Pod, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "render-build-pod"})
+	return &kubeapi.Pod{}, nil
+}
+
 func (c *Fake) CreateBuildConfig(config *buildapi.BuildConfig) (*buildapi.BuildConfig, error) {
 	c.Actions = append(c.Actions, FakeAction{Action: "create-buildconfig"})
 	return &buildapi.BuildConfig{}, nil
@@ -64,6 +99,21 @@ func (c *Fake) DeleteBuildConfig(id string) error {
 	return nil
 }
 
+func (c *Fake) InstantiateBuildConfig(id string, request *buildapi.BuildRequest) (*buildapi.Build, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "instantiate-buildconfig", Value: id})
+	return &buildapi.Build{}, nil
+}
+
+func (c *Fake) RenderBuildConfigPod(config *buildapi.BuildConfig) (*kubeapi.Pod, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "render-buildconfig-pod"})
+	return &kubeapi.Pod{}, nil
+}
+
+func (c *Fake) UploadBuildConfigArchive(id string, archive io.Reader) (*buildapi.Build, error) {
+	c.Actions = append(c.Actions, FakeAction{Action: "upload-buildconfig-archive", Value: id})
+	return &buildapi.Build{}, nil
+}
+
 func (c *Fake) ListImages(selector labels.Selector) (*imageapi.ImageList, error) {
 	c.Actions = append(c.Actions, FakeAction{Action: "list-images"})
 	return &imageapi.ImageList{}, nil
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	kubeclient "github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+// streamClient performs GET requests whose response body is returned to the
+// caller as it is received, such as followed build logs. It reaches the server
+// the way the RESTClient does: with the same credentials and TLS settings.
+type streamClient struct {
+	prefix     url.URL
+	auth       *kubeclient.AuthInfo
+	httpClient *http.Client
+}
+
+func newStreamClient(host string, auth *kubeclient.AuthInfo, prefix string) (*streamClient, error) {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	hostURL.Path = path.Join(hostURL.Path, prefix)
+	return &streamClient{
+		prefix: *hostURL,
+		auth:   auth,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: true,
+				},
+			},
+		},
+	}, nil
+}
+
+// Get requests the resource at location, relative to the API prefix, and
+// returns the body of the response. A response other than 200 OK is returned
+// as an error carrying the message of the server.
+func (c *streamClient) Get(location string, query url.Values) (io.ReadCloser, error) {
+	target := c.prefix
+	target.Path = path.Join(target.Path, location)
+	target.RawQuery = query.Encode()
+	req, err := http.NewRequest("GET", target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.auth != nil {
+		req.SetBasicAuth(c.auth.User, c.auth.Password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server reported %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,84 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/openshift/origin/pkg/build/api"
+)
+
+// DescribeBuild writes a detailed, human readable description of build to w,
+// including the history of its status transitions.
+func DescribeBuild(build *api.Build, w io.Writer) error {
+	out := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	fmt.Fprintf(out, "ID:\t%s\n", build.ID)
+	fmt.Fprintf(out, "Status:\t%s\n", build.Status)
+	if len(build.Reason) > 0 {
+		fmt.Fprintf(out, "Reason:\t%s\n", build.Reason)
+	}
+	if len(build.Message) > 0 {
+		fmt.Fprintf(out, "Message:\t%s\n", build.Message)
+	}
+	fmt.Fprintf(out, "Type:\t%s\n", build.Input.Type)
+	fmt.Fprintf(out, "Source:\t%s\n", describeSource(&build.Input.Source))
+	fmt.Fprintf(out, "Image:\t%s\n", build.Input.ImageTag)
+	if build.Config != nil {
+		fmt.Fprintf(out, "Config:\t%s (build %d)\n", build.Config.ID, build.Config.Version)
+	}
+	if build.Revision != nil {
+		if len(build.Revision.Commit) > 0 {
+			fmt.Fprintf(out, "Commit:\t%s\n", build.Revision.Commit)
+		}
+		if len(build.Revision.BuilderImageID) > 0 {
+			fmt.Fprintf(out, "Builder Image ID:\t%s\n", build.Revision.BuilderImageID)
+		}
+	}
+	if len(build.ClonedFrom) > 0 {
+		fmt.Fprintf(out, "Cloned From:\t%s\n", build.ClonedFrom)
+	}
+	if cause := build.UpstreamBuildCause; cause != nil {
+		fmt.Fprintf(out, "Upstream Build:\t%s (build config %s)\n", cause.Build, cause.BuildConfig)
+	}
+	fmt.Fprintf(out, "Pod ID:\t%s\n", build.PodID)
+	if build.Attempt > 0 {
+		fmt.Fprintf(out, "Attempt:\t%d\n", build.Attempt)
+	}
+	fmt.Fprintf(out, "Created:\t%s\n", formatTime(build.CreationTimestamp))
+	fmt.Fprintf(out, "Started:\t%s\n", formatTime(build.StartTimestamp))
+	fmt.Fprintf(out, "Completed:\t%s\n", formatTime(build.CompletionTimestamp))
+	if build.Duration > 0 {
+		fmt.Fprintf(out, "Duration:\t%v\n", build.Duration)
+	}
+
+	if len(build.StatusHistory) > 0 {
+		fmt.Fprintf(out, "\nStatus History:\n")
+		fmt.Fprintf(out, "  Time\tFrom\tTo\tAfter\tMessage\n")
+		previous := build.CreationTimestamp
+		for _, transition := range build.StatusHistory {
+			fmt.Fprintf(out, "  %s\t%s\t%s\t%s\t%s\n", formatTime(transition.Timestamp),
+				transition.From, transition.To, formatElapsed(previous, transition.Timestamp), transition.Message)
+			previous = transition.Timestamp
+		}
+	}
+	return out.Flush()
+}
+
+// formatTime formats t for display, an unset time is left empty
+func formatTime(t util.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatElapsed returns the time spent between from and to, which is how long a
+// build stayed in a status before its next transition
+func formatElapsed(from, to util.Time) string {
+	if from.IsZero() || to.IsZero() {
+		return ""
+	}
+	return to.Sub(from.Time).String()
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/openshift/origin/pkg/build/api"
 )
 
-var buildColumns = []string{"ID", "Status", "Pod ID"}
-var buildConfigColumns = []string{"ID", "Type", "SourceURI"}
+var buildColumns = []string{"ID", "Status", "Pod ID", "Reason", "Message"}
+var buildConfigColumns = []string{"ID", "Type", "Source"}
 
 // RegisterPrintHandlers registers HumanReadablePrinter handlers
 // for build and buildConfig resources.
@@ -21,7 +21,11 @@ func RegisterPrintHandlers(printer *kubecfg.HumanReadablePrinter) {
 }
 
 func printBuild(build *api.Build, w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", build.ID, build.Status, build.PodID)
+	status := string(build.Status)
+	if build.Status == api.BuildQueued && build.QueuePosition > 0 {
+		status = fmt.Sprintf("%s (%d)", build.Status, build.QueuePosition)
+	}
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", build.ID, status, build.PodID, build.Reason, build.Message)
 	return err
 }
 func printBuildList(buildList *api.BuildList, w io.Writer) error {
@@ -34,9 +38,27 @@ func printBuildList(buildList *api.BuildList, w io.Writer) error {
 }
 
 func printBuildConfig(bc *api.BuildConfig, w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", bc.ID, bc.DesiredInput.Type, bc.DesiredInput.SourceURI)
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", bc.ID, bc.DesiredInput.Type, describeSource(&bc.DesiredInput.Source))
 	return err
 }
+
+// describeSource returns a short description of the source of a build
+func describeSource(source *api.BuildSource) string {
+	switch {
+	case source.Type == api.GitBuildSourceType && source.Git != nil:
+		description := source.Git.URI
+		if len(source.Git.Ref) > 0 {
+			description += "#" + source.Git.Ref
+		}
+		if len(source.Git.ContextDir) > 0 {
+			description += " (" + source.Git.ContextDir + ")"
+		}
+		return description
+	case source.Type == api.ArchiveBuildSourceType && source.Archive != nil:
+		return source.Archive.URL
+	}
+	return string(source.Type)
+}
 func printBuildConfigList(buildList *api.BuildConfigList, w io.Writer) error {
 	for _, buildConfig := range buildList.Items {
 		if err := printBuildConfig(&buildConfig, w); err != nil {
@@ -45,5 +45,8 @@ func NewCommandKubecfg(name string) *cobra.Command {
 	flag.StringVar(&cfg.WWW, "www", "", "If -proxy is true, use this directory to serve static files")
 	flag.StringVar(&cfg.TemplateFile, "template_file", "", "If present, load this file as a golang template and use it for output printing")
 	flag.StringVar(&cfg.TemplateStr, "template", "", "If present, parse this string as a golang template and use it for output printing")
+	flag.BoolVarP(&cfg.Follow, "follow", "f", false, "If true, stream the build log until the build finishes, only used with 'buildLogs'")
+	flag.StringVar(&cfg.Ref, "ref", "", "The git ref to build instead of the ref of the build config, only used with 'startBuild'")
+	flag.StringVar(&cfg.BuilderImage, "builder_image", "", "The builder image to use instead of the image of the build config, only used with 'startBuild'")
 	return cmd
 }
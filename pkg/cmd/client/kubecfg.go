@@ -18,6 +18,7 @@ package client
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -64,6 +65,9 @@ type KubeConfig struct {
 	WWW           string
 	TemplateFile  string
 	TemplateStr   string
+	Follow        bool
+	Ref           string
+	BuilderImage  string
 
 	Args []string
 }
@@ -173,8 +177,9 @@ func (c *KubeConfig) Run() {
 
 	// TODO: this won't work if TLS is enabled with client cert auth, but no
 	// passwords are required. Refactor when we address client auth abstraction.
+	var auth *kubeclient.AuthInfo
 	if kubeClient.Secure() {
-		auth, err := kubecfg.LoadAuthInfo(c.AuthConfig, os.Stdin)
+		auth, err = kubecfg.LoadAuthInfo(c.AuthConfig, os.Stdin)
 		if err != nil {
 			glog.Fatalf("Error loading auth: %v", err)
 		}
@@ -229,7 +234,7 @@ func (c *KubeConfig) Run() {
 		"imageRepositoryMappings": {"ImageRepositoryMapping", client.RESTClient},
 	}
 
-	matchFound := c.executeConfigRequest(method, clients) || c.executeControllerRequest(method, kubeClient) || c.executeAPIRequest(method, clients)
+	matchFound := c.executeConfigRequest(method, clients) || c.executeControllerRequest(method, kubeClient) || c.executeBuildRequest(method, client) || c.executeAPIRequest(method, clients)
 	if matchFound == false {
 		glog.Fatalf("Unknown command %s", method)
 	}
@@ -425,6 +430,139 @@ func (c *KubeConfig) executeControllerRequest(method string, client *kubeclient.
 	return true
 }
 
+func (c *KubeConfig) executeBuildRequest(method string, client *osclient.Client) bool {
+	switch method {
+	case "buildLogs":
+		if len(c.Args) != 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] buildLogs <build-id> [-f]")
+		}
+		log, err := client.BuildLogs(c.Arg(1), c.Follow)
+		if err != nil {
+			glog.Fatalf("Unable to retrieve the log of build %s: %v", c.Arg(1), err)
+		}
+		defer log.Close()
+		if _, err := io.Copy(os.Stdout, log); err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+	case "cancelBuild":
+		if len(c.Args) != 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] cancelBuild <build-id>")
+		}
+		build, err := client.CancelBuild(c.Arg(1))
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		if err := humanReadablePrinter().PrintObj(build, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	case "cloneBuild":
+		if len(c.Args) != 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] cloneBuild <build-id>")
+		}
+		build, err := client.CloneBuild(c.Arg(1))
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		if err := humanReadablePrinter().PrintObj(build, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	case "startBuild":
+		if len(c.Args) < 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] startBuild <build-config-id> [--ref=<ref>] [--builder_image=<image>] [NAME=VALUE...]")
+		}
+		request := &buildapi.BuildRequest{
+			Ref:          c.Ref,
+			BuilderImage: c.BuilderImage,
+		}
+		for _, arg := range c.Args[2:] {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || len(parts[0]) == 0 {
+				glog.Fatalf("Invalid environment variable %q, expected NAME=VALUE", arg)
+			}
+			request.Env = append(request.Env, api.EnvVar{Name: parts[0], Value: parts[1]})
+		}
+		build, err := client.InstantiateBuildConfig(c.Arg(1), request)
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		if err := humanReadablePrinter().PrintObj(build, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	case "uploadBuild":
+		if len(c.Args) != 3 {
+			glog.Fatal("usage: kubecfg [OPTIONS] uploadBuild <build-config-id> <archive>")
+		}
+		archive, err := os.Open(c.Arg(2))
+		if err != nil {
+			glog.Fatalf("Unable to read the archive: %v", err)
+		}
+		defer archive.Close()
+		build, err := client.UploadBuildConfigArchive(c.Arg(1), archive)
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		if err := humanReadablePrinter().PrintObj(build, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	case "renderBuildPod":
+		if len(c.Args) != 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] renderBuildPod builds|buildConfigs[/<id>] [-c <file>]")
+		}
+		storage, path, hasID := storagePathFromArg(c.Arg(1))
+		id := strings.TrimPrefix(path, storage+"/")
+		var pod *api.Pod
+		var err error
+		switch storage {
+		case "builds":
+			obj := &buildapi.Build{}
+			if hasID {
+				obj, err = client.GetBuild(id)
+			} else {
+				err = runtime.DecodeInto(c.readConfig(storage), obj)
+			}
+			if err == nil {
+				pod, err = client.RenderBuildPod(obj)
+			}
+		case "buildConfigs":
+			obj := &buildapi.BuildConfig{}
+			if hasID {
+				obj, err = client.GetBuildConfig(id)
+			} else {
+				err = runtime.DecodeInto(c.readConfig(storage), obj)
+			}
+			if err == nil {
+				pod, err = client.RenderBuildConfigPod(obj)
+			}
+		default:
+			glog.Fatalf("Unable to render the build pod of %s, expected builds or buildConfigs", storage)
+		}
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		var printer kubecfg.ResourcePrinter = &kubecfg.YAMLPrinter{}
+		if c.JSON {
+			printer = &kubecfg.IdentityPrinter{}
+		}
+		if err := printer.PrintObj(pod, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	case "describeBuild":
+		if len(c.Args) != 2 {
+			glog.Fatal("usage: kubecfg [OPTIONS] describeBuild <build-id>")
+		}
+		result, err := client.GetBuild(c.Arg(1))
+		if err != nil {
+			glog.Fatalf("Error: %v", err)
+		}
+		if err := build.DescribeBuild(result, os.Stdout); err != nil {
+			glog.Fatalf("Failed to print: %v", err)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
 func (c *KubeConfig) executeConfigRequest(method string, clients ClientMappings) bool {
 	if method != "apply" {
 		return false
@@ -1,9 +1,13 @@
 package master
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
@@ -26,8 +30,10 @@ import (
 	"github.com/google/cadvisor/client"
 	"github.com/spf13/cobra"
 
+	osapiserver "github.com/openshift/origin/pkg/apiserver"
 	"github.com/openshift/origin/pkg/build"
 	buildapi "github.com/openshift/origin/pkg/build/api"
+	"github.com/openshift/origin/pkg/build/archive"
 	buildregistry "github.com/openshift/origin/pkg/build/registry/build"
 	buildconfigregistry "github.com/openshift/origin/pkg/build/registry/buildconfig"
 	"github.com/openshift/origin/pkg/build/strategy"
@@ -35,10 +41,13 @@ import (
 	"github.com/openshift/origin/pkg/build/webhook/github"
 	osclient "github.com/openshift/origin/pkg/client"
 	"github.com/openshift/origin/pkg/cmd/util/docker"
+	"github.com/openshift/origin/pkg/election"
 	imageetcd "github.com/openshift/origin/pkg/image/registry/etcd"
 	"github.com/openshift/origin/pkg/image/registry/image"
 	"github.com/openshift/origin/pkg/image/registry/imagerepository"
 	"github.com/openshift/origin/pkg/image/registry/imagerepositorymapping"
+	oskubelet "github.com/openshift/origin/pkg/kubelet"
+	"github.com/openshift/origin/pkg/metrics"
 	"github.com/openshift/origin/pkg/template"
 
 	// Register versioned api types
@@ -47,6 +56,10 @@ import (
 	_ "github.com/openshift/origin/pkg/template/api/v1beta1"
 )
 
+// minionPort is the port of the kubelet API on every node, which the masters
+// reach to read pod information, container logs and build results.
+const minionPort = 10250
+
 func NewCommandStartAllInOne(name string) *cobra.Command {
 	dockerHelper := docker.NewHelper()
 	cfg := &config{Docker: *dockerHelper}
@@ -133,7 +146,7 @@ func (c *config) startAllInOne() {
 	c.runProxy()
 	c.runScheduler()
 	c.runReplicationController()
-	c.runBuildController()
+	c.runLeaderControllers()
 
 	select {}
 }
@@ -143,7 +156,7 @@ func (c *config) startMaster() {
 	c.runApiserver()
 	c.runScheduler()
 	c.runReplicationController()
-	c.runBuildController()
+	c.runLeaderControllers()
 
 	select {}
 }
@@ -156,7 +169,6 @@ func (c *config) startNode() {
 }
 
 func (c *config) runApiserver() {
-	minionPort := 10250
 	osAddr := c.ListenAddr
 
 	kubePrefix := "/api/v1beta1"
@@ -166,12 +178,17 @@ func (c *config) runApiserver() {
 	osClient := c.getOsClient()
 	etcdClient, etcdServers := c.getEtcdClient()
 
+	buildRegistry := build.NewEtcdRegistry(etcdClient)
 	imageRegistry := imageetcd.NewEtcd(etcdClient)
 
+	archiveStore := c.archiveStore()
+	buildPodAPIURL := c.buildPodAPIURL()
+	credentials := build.NewCredentialsIssuer(buildRegistry, buildPodAPIURL)
+
 	// initialize OpenShift API
 	storage := map[string]apiserver.RESTStorage{
-		"builds":                  buildregistry.NewStorage(build.NewEtcdRegistry(etcdClient)),
-		"buildConfigs":            buildconfigregistry.NewStorage(build.NewEtcdRegistry(etcdClient)),
+		"builds":                  buildregistry.NewStorage(buildRegistry),
+		"buildConfigs":            buildconfigregistry.NewStorage(buildRegistry),
 		"images":                  image.NewREST(imageRegistry),
 		"imageRepositories":       imagerepository.NewREST(imageRegistry),
 		"imageRepositoryMappings": imagerepositorymapping.NewREST(imageRegistry, imageRegistry),
@@ -202,14 +219,32 @@ func (c *config) runApiserver() {
 	m := master.New(masterConfig)
 
 	apiserver.NewAPIGroup(m.API_v1beta1()).InstallREST(osMux, kubePrefix)
-	apiserver.NewAPIGroup(storage, runtime.Codec).InstallREST(osMux, osPrefix)
+	osAPI := http.NewServeMux()
+	apiserver.NewAPIGroup(storage, runtime.Codec).InstallREST(osAPI, osPrefix)
+
+	// initialize operations on individual OpenShift resources
+	subresources := osapiserver.NewSubresourceHandler(osPrefix, osAPI)
+	subresources.Handle("builds", "log", buildregistry.NewLogHandler(buildRegistry, kubeClient, minionPort))
+	subresources.Handle("builds", "cancel", buildregistry.NewCancelHandler(buildRegistry))
+	subresources.Handle("builds", "clone", buildregistry.NewCloneHandler(buildRegistry, buildRegistry, archiveStore))
+	subresources.Handle("builds", "archive", buildregistry.NewArchiveHandler(archiveStore))
+	subresources.Handle("builds", "credentials", buildregistry.NewCredentialsHandler(buildRegistry, buildRegistry, buildRegistry))
+	subresources.Handle("buildConfigs", "instantiate", buildconfigregistry.NewInstantiateHandler(buildRegistry, buildRegistry))
+	subresources.Handle("buildConfigs", "upload", buildconfigregistry.NewUploadHandler(buildRegistry, buildRegistry, archiveStore, buildPodAPIURL))
+	osMux.Handle(osPrefix+"/", subresources)
+
+	// render build pods without creating them
+	buildStrategies, dockerRegistry := c.buildStrategies()
+	osMux.Handle(osPrefix+"/renderBuildPod", build.NewPodRenderer(buildStrategies, dockerRegistry, buildRegistry, credentials))
 	apiserver.InstallSupport(osMux)
+	osMux.Handle("/metrics", metrics.Handler())
 
+	// the responses have no write deadline, followed build logs and watches are
+	// streamed for as long as builds run
 	osApi := &http.Server{
 		Addr:           osAddr,
 		Handler:        apiserver.RecoverPanics(osMux),
 		ReadTimeout:    5 * time.Minute,
-		WriteTimeout:   5 * time.Minute,
 		MaxHeaderBytes: 1 << 20,
 	}
 
@@ -220,10 +255,26 @@ func (c *config) runApiserver() {
 	}, 0)
 }
 
+// archiveStore returns the store of uploaded build archives. Build pods download
+// their archive from whichever master answers at the build pod API URL, so with
+// several masters OPENSHIFT_BUILD_ARCHIVE_DIR must be a directory shared by all
+// of them, e.g. on NFS.
+func (c *config) archiveStore() *archive.Store {
+	archiveDir := env("OPENSHIFT_BUILD_ARCHIVE_DIR", path.Join(os.TempDir(), "openshift-build-archives"))
+	archiveMaxMB, err := strconv.Atoi(env("OPENSHIFT_BUILD_ARCHIVE_MAX_MB", "100"))
+	if err != nil || archiveMaxMB <= 0 {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_ARCHIVE_MAX_MB, expected a positive number of megabytes: %v", err)
+	}
+	archiveStore, err := archive.NewStore(archiveDir, int64(archiveMaxMB)<<20)
+	if err != nil {
+		glog.Fatalf("Unable to create the build archive directory %s: %v", archiveDir, err)
+	}
+	return archiveStore
+}
+
 func (c *config) runKubelet() {
 	rootDirectory := path.Clean("/var/lib/openshift")
 	minionHost := c.bindAddr
-	minionPort := 10250
 
 	cadvisorClient, err := cadvisor.NewClient("http://" + c.masterHost + ":4194")
 	if err != nil {
@@ -251,9 +302,27 @@ func (c *config) runKubelet() {
 		rootDirectory,
 		30*time.Second)
 	go util.Forever(func() { k.Run(cfg.Updates()) }, 0)
+
+	// the kubelet API is extended with the output of containers, which the
+	// build log and the build controller read
+	kubeletServer := kubelet.NewServer(k, cfg.Channel("http"))
+	kubeletMux := http.NewServeMux()
+	kubeletMux.Handle("/", &kubeletServer)
+	kubeletMux.Handle(oskubelet.ContainerLogsPrefix, oskubelet.NewContainerLogsHandler(k, dockerClient))
+	kubeletAPI := &http.Server{
+		Addr:           net.JoinHostPort(minionHost, strconv.Itoa(minionPort)),
+		Handler:        kubeletMux,
+		ReadTimeout:    10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
 	go util.Forever(func() {
-		kubelet.ListenAndServeKubeletServer(k, cfg.Channel("http"), minionHost, uint(minionPort))
+		glog.Infof("Started Kubelet API at http://%s", kubeletAPI.Addr)
+		glog.Error(kubeletAPI.ListenAndServe())
 	}, 0)
+
+	// the result directories of build pods outlive the pods on the node
+	resultDirReaper := build.NewResultDirReaper(strategy.BuildResultHostDir, c.buildPodTTL())
+	resultDirReaper.Run(10*time.Minute, nil)
 }
 
 func (c *config) runProxy() {
@@ -303,28 +372,148 @@ func (c *config) runScheduler() {
 	// initialize scheduler
 	configFactory := &factory.ConfigFactory{Client: kubeClient}
 	config := configFactory.Create()
+	// build pods may be restricted to dedicated build minions
+	config.Algorithm = build.NewMinionSelectingScheduler(config.Algorithm, c.getOsClient())
 	s := scheduler.New(config)
 	s.Run()
 	glog.Infof("Started Kubernetes Scheduler")
 }
 
-func (c *config) runBuildController() {
+// runLeaderControllers runs the OpenShift controllers while this master holds the
+// controller lease, so that a single one of several masters manages builds. A
+// master which loses the lease stops its controllers, while its API server keeps
+// serving, and waits to acquire the lease again.
+func (c *config) runLeaderControllers() {
+	leaseTTL, err := strconv.Atoi(env("OPENSHIFT_CONTROLLER_LEASE_TTL", "30"))
+	if err != nil || leaseTTL <= 0 {
+		glog.Fatalf("Invalid OPENSHIFT_CONTROLLER_LEASE_TTL, expected a positive number of seconds: %v", err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("Unable to determine the hostname: %v", err)
+	}
+	// the process ID makes the identity unique, a restarted master waits for the
+	// lease of its previous process to expire
+	leaseID := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	renewPeriod := time.Duration(leaseTTL) * time.Second / 3
+
+	etcdClient, _ := c.getEtcdClient()
+	lease := election.NewLease(etcdClient, "/leases/openshift-controllers", leaseID, uint64(leaseTTL))
+
+	go func() {
+		for {
+			lease.Acquire(renewPeriod)
+			stop := make(chan struct{})
+			c.runBuildController(stop)
+			lease.Hold(renewPeriod)
+			glog.Errorf("Lost the controller lease, stopping the controllers so that another master takes over")
+			close(stop)
+		}
+	}()
+}
+
+// runBuildController starts the build controllers, which run until stop is closed.
+func (c *config) runBuildController(stop <-chan struct{}) {
 	kubeClient := c.getKubeClient()
 	osClient := c.getOsClient()
 
 	// initialize build controller
+	buildStrategies, dockerRegistry := c.buildStrategies()
+	buildTimeout, err := strconv.Atoi(env("OPENSHIFT_BUILD_TIMEOUT", "1200"))
+	if err != nil {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_TIMEOUT, expected a number of seconds: %v", err)
+	}
+	maxConcurrentBuilds, err := strconv.Atoi(env("OPENSHIFT_MAX_CONCURRENT_BUILDS", "0"))
+	if err != nil {
+		glog.Fatalf("Invalid OPENSHIFT_MAX_CONCURRENT_BUILDS, expected a number of builds: %v", err)
+	}
+
+	// the result is read while syncing builds, a kubelet which does not respond in
+	// time leaves the output of the build unrecorded
+	resultReader := build.NewKubeletResultReader(minionPort, 10*time.Second)
+
+	// the credentials of build configs are only available from storage
+	etcdClient, _ := c.getEtcdClient()
+	buildConfigs := build.NewEtcdRegistry(etcdClient)
+
+	credentials := build.NewCredentialsIssuer(buildConfigs, c.buildPodAPIURL())
+
+	// the watch reports the changes of builds, the resync is only a fallback, while
+	// running builds are polled to notice that their pods have terminated
+	resyncPeriod, err := strconv.Atoi(env("OPENSHIFT_BUILD_RESYNC_PERIOD", "300"))
+	if err != nil || resyncPeriod <= 0 {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_RESYNC_PERIOD, expected a positive number of seconds: %v", err)
+	}
+	pollPeriod, err := strconv.Atoi(env("OPENSHIFT_BUILD_POLL_PERIOD", "10"))
+	if err != nil || pollPeriod <= 0 {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_POLL_PERIOD, expected a positive number of seconds: %v", err)
+	}
+
+	buildController := build.NewBuildController(kubeClient, osClient, buildStrategies, dockerRegistry, buildTimeout, maxConcurrentBuilds, resultReader, buildConfigs, credentials)
+	buildController.Run(time.Duration(resyncPeriod)*time.Second, time.Duration(pollPeriod)*time.Second, stop)
+
+	buildPodReaper := build.NewBuildPodReaper(kubeClient, osClient, c.buildPodTTL())
+	buildPodReaper.Run(10*time.Minute, stop)
+
+	imageChangeController := build.NewImageChangeController(osClient, dockerRegistry)
+	imageChangeController.Run(10*time.Second, stop)
+
+	// the archives are reaped by the master holding the controller lease only, as
+	// the archive directory is shared by the masters. The archives of failed
+	// builds are retained so that the builds can be re-run.
+	archiveRetention, err := strconv.Atoi(env("OPENSHIFT_BUILD_ARCHIVE_RETENTION", "86400"))
+	if err != nil || archiveRetention < 0 {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_ARCHIVE_RETENTION, expected a number of seconds: %v", err)
+	}
+	archiveReaper := archive.NewReaper(c.archiveStore(), buildConfigs, time.Hour, time.Duration(archiveRetention)*time.Second)
+	archiveReaper.Run(time.Minute, stop)
+}
+
+// buildPodTTL returns how long the pod of a finished build is kept, so that the
+// logs of the build remain available.
+func (c *config) buildPodTTL() time.Duration {
+	buildPodTTL, err := strconv.Atoi(env("OPENSHIFT_BUILD_POD_TTL", "86400"))
+	if err != nil {
+		glog.Fatalf("Invalid OPENSHIFT_BUILD_POD_TTL, expected a number of seconds: %v", err)
+	}
+	return time.Duration(buildPodTTL) * time.Second
+}
+
+// buildPodAPIURL returns the URL of the OpenShift API of the masters as seen
+// from build pods, which fetch their archive and credentials from it. It
+// defaults to the advertised address of the master, unless that is a loopback
+// address which containers can not reach. The URL is then empty, and only the
+// builds which need it fail.
+func (c *config) buildPodAPIURL() string {
+	masterURL := env("OPENSHIFT_MASTER_URL", "")
+	if len(masterURL) == 0 {
+		_, port, err := net.SplitHostPort(c.ListenAddr)
+		if err != nil {
+			glog.Fatalf("Invalid listen address %s: %v", c.ListenAddr, err)
+		}
+		if ip := net.ParseIP(c.masterHost); c.masterHost == "localhost" || (ip != nil && ip.IsLoopback()) {
+			glog.Warningf("The master address %s is not reachable from build pods, set OPENSHIFT_MASTER_URL to run builds from uploaded archives or with credentials", c.masterHost)
+			return ""
+		}
+		masterURL = "http://" + net.JoinHostPort(c.masterHost, port)
+	}
+	return strings.TrimRight(masterURL, "/") + "/osapi/v1beta1"
+}
+
+// buildStrategies returns the strategies which create build pods and the Docker
+// registry the builds push to, shared by the build controller and the API
+// rendering build pods.
+func (c *config) buildStrategies() (map[buildapi.BuildType]build.BuildJobStrategy, string) {
 	dockerBuilderImage := env("OPENSHIFT_DOCKER_BUILDER_IMAGE", "openshift/docker-builder")
 	useHostDockerSocket := len(env("USE_HOST_DOCKER_SOCKET", "")) > 0
 	stiBuilderImage := env("OPENSHIFT_STI_BUILDER_IMAGE", "openshift/sti-builder")
 	dockerRegistry := env("DOCKER_REGISTRY", "")
 
-	buildStrategies := map[buildapi.BuildType]build.BuildJobStrategy{
+	return map[buildapi.BuildType]build.BuildJobStrategy{
 		buildapi.DockerBuildType: strategy.NewDockerBuildStrategy(dockerBuilderImage, useHostDockerSocket),
 		buildapi.STIBuildType:    strategy.NewSTIBuildStrategy(stiBuilderImage, useHostDockerSocket),
-	}
-
-	buildController := build.NewBuildController(kubeClient, osClient, buildStrategies, dockerRegistry, 1200)
-	buildController.Run(10 * time.Second)
+		buildapi.CustomBuildType: strategy.NewCustomBuildStrategy(),
+	}, dockerRegistry
 }
 
 func env(key string, defaultValue string) string {
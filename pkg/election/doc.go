@@ -0,0 +1,3 @@
+// Package election contains a leader lease stored in etcd, which lets several
+// OpenShift masters run controllers that must only run in a single process.
+package election
@@ -0,0 +1,104 @@
+package election
+
+import (
+	"errors"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/golang/glog"
+)
+
+// ErrLeaseLost is returned when a lease is renewed after another process took it over.
+var ErrLeaseLost = errors.New("the lease is held by another process")
+
+// Lease elects a single leader among the processes sharing an etcd key. The key
+// holds the identity of the leader and expires unless the leader renews it.
+type Lease struct {
+	client tools.EtcdGetSet
+	key    string
+	id     string
+	ttl    uint64
+
+	// renewed is the time the lease was last acquired or renewed by this process
+	renewed time.Time
+}
+
+// NewLease creates a lease on key for the process identified by id. The lease
+// expires ttl seconds after it was last acquired or renewed.
+func NewLease(client tools.EtcdGetSet, key, id string, ttl uint64) *Lease {
+	return &Lease{
+		client: client,
+		key:    key,
+		id:     id,
+		ttl:    ttl,
+	}
+}
+
+// Acquire blocks until the lease is held by this process. A standby process
+// retries every period and takes over once the lease of the leader expires.
+func (l *Lease) Acquire(period time.Duration) {
+	for {
+		held, err := l.tryAcquire()
+		if err != nil {
+			glog.Errorf("Error acquiring lease %s: %v", l.key, err)
+		}
+		if held {
+			glog.Infof("Acquired lease %s as %s", l.key, l.id)
+			return
+		}
+		time.Sleep(period)
+	}
+}
+
+// tryAcquire makes a single attempt to acquire the lease and returns whether it
+// is held by this process. The identity of a process is unique, so a lease which
+// exists is held by another process and is only taken over once it expires.
+func (l *Lease) tryAcquire() (bool, error) {
+	start := time.Now()
+	_, err := l.client.Create(l.key, l.id, l.ttl)
+	if err == nil {
+		l.renewed = start
+		return true, nil
+	}
+	if tools.IsEtcdNodeExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Renew extends the lease by its ttl. It returns ErrLeaseLost if the lease
+// expired or is held by another process.
+func (l *Lease) Renew() error {
+	start := time.Now()
+	_, err := l.client.CompareAndSwap(l.key, l.id, l.ttl, l.id, 0)
+	if tools.IsEtcdTestFailed(err) || tools.IsEtcdNotFound(err) {
+		return ErrLeaseLost
+	}
+	if err == nil {
+		l.renewed = start
+	}
+	return err
+}
+
+// Hold renews the lease every period and returns once it is lost, either because
+// another process took it over or because it could not be renewed and may expire
+// before the next attempt.
+func (l *Lease) Hold(period time.Duration) {
+	ttl := time.Duration(l.ttl) * time.Second
+	for {
+		time.Sleep(period)
+		err := l.Renew()
+		if err == nil {
+			continue
+		}
+		if err == ErrLeaseLost {
+			glog.Errorf("Lost lease %s to another process", l.key)
+			return
+		}
+		glog.Errorf("Error renewing lease %s: %v", l.key, err)
+		if time.Since(l.renewed) >= ttl-period {
+			glog.Errorf("Lease %s could not be renewed for %v, stepping down before it expires", l.key, time.Since(l.renewed))
+			return
+		}
+	}
+}
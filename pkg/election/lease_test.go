@@ -0,0 +1,140 @@
+package election
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+const leaseKey = "/leases/test"
+
+func newFakeClient(t *testing.T, holder string) *tools.FakeEtcdClient {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	if len(holder) > 0 {
+		fakeClient.Data[leaseKey] = tools.EtcdResponseWithError{
+			R: &etcd.Response{
+				Node: &etcd.Node{
+					Value:         holder,
+					ModifiedIndex: 1,
+				},
+			},
+		}
+		fakeClient.ChangeIndex = 1
+	}
+	return fakeClient
+}
+
+func TestAcquireFreeLease(t *testing.T) {
+	fakeClient := newFakeClient(t, "")
+	lease := NewLease(fakeClient, leaseKey, "master1", 30)
+
+	held, err := lease.tryAcquire()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !held {
+		t.Errorf("Expected the free lease to be acquired")
+	}
+	if holder := fakeClient.Data[leaseKey].R.Node.Value; holder != "master1" {
+		t.Errorf("Expected the lease to be held by master1, got %s", holder)
+	}
+}
+
+func TestAcquireHeldLease(t *testing.T) {
+	fakeClient := newFakeClient(t, "master2")
+	lease := NewLease(fakeClient, leaseKey, "master1", 30)
+
+	held, err := lease.tryAcquire()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if held {
+		t.Errorf("Expected the lease held by another master not to be acquired")
+	}
+	if holder := fakeClient.Data[leaseKey].R.Node.Value; holder != "master2" {
+		t.Errorf("Expected the lease to be held by master2, got %s", holder)
+	}
+}
+
+func TestAcquireExpiredLease(t *testing.T) {
+	fakeClient := newFakeClient(t, "master2")
+	lease := NewLease(fakeClient, leaseKey, "master1", 30)
+	fakeClient.Delete(leaseKey, false)
+
+	lease.Acquire(time.Millisecond)
+
+	if holder := fakeClient.Data[leaseKey].R.Node.Value; holder != "master1" {
+		t.Errorf("Expected the expired lease to be taken over by master1, got %s", holder)
+	}
+}
+
+func TestRenewLostLease(t *testing.T) {
+	fakeClient := newFakeClient(t, "master2")
+	lease := NewLease(fakeClient, leaseKey, "master1", 30)
+
+	if err := lease.Renew(); err != ErrLeaseLost {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+}
+
+func TestHoldReturnsWhenLost(t *testing.T) {
+	fakeClient := newFakeClient(t, "master2")
+	lease := NewLease(fakeClient, leaseKey, "master1", 30)
+
+	done := make(chan struct{})
+	go func() {
+		lease.Hold(time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Expected Hold to return once the lease is lost")
+	}
+}
+
+func TestHoldReturnsWhenExpired(t *testing.T) {
+	fakeClient := newFakeClient(t, "master1")
+	fakeClient.Err = errors.New("unreachable")
+	lease := NewLease(fakeClient, leaseKey, "master1", 0)
+
+	done := make(chan struct{})
+	go func() {
+		lease.Hold(time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Expected Hold to return once the lease could not be renewed within its ttl")
+	}
+}
+
+func TestHoldReturnsBeforeExpiry(t *testing.T) {
+	fakeClient := newFakeClient(t, "")
+	lease := NewLease(fakeClient, leaseKey, "master1", 2)
+	if held, err := lease.tryAcquire(); !held || err != nil {
+		t.Fatalf("Expected the lease to be acquired: %v", err)
+	}
+	fakeClient.Err = errors.New("unreachable")
+
+	done := make(chan struct{})
+	go func() {
+		lease.Hold(time.Second)
+		close(done)
+	}()
+
+	// the lease expires 2 seconds after it was acquired, the next renewal would
+	// be too late
+	select {
+	case <-done:
+	case <-time.After(1500 * time.Millisecond):
+		t.Errorf("Expected Hold to return before the lease expires")
+	}
+}
@@ -136,9 +136,17 @@ func (r *Etcd) CreateImageRepository(repo *api.ImageRepository) error {
 	return err
 }
 
-// UpdateImageRepository replaces an existing ImageRepository in the registry with the given ImageRepository.
+// UpdateImageRepository replaces an existing ImageRepository in the registry with the given ImageRepository,
+// if it has not been modified since the ResourceVersion of repo. A conflict error is returned otherwise.
 func (r *Etcd) UpdateImageRepository(repo *api.ImageRepository) error {
-	return r.SetObj(makeImageRepositoryKey(repo.ID), repo)
+	err := r.SetObj(makeImageRepositoryKey(repo.ID), repo)
+	switch {
+	case tools.IsEtcdTestFailed(err), tools.IsEtcdNodeExist(err):
+		return apierrors.NewConflict("imageRepository", repo.ID, err)
+	case tools.IsEtcdNotFound(err):
+		return apierrors.NewNotFound("imageRepository", repo.ID)
+	}
+	return err
 }
 
 // DeleteImageRepository deletes an ImageRepository by id.
@@ -507,6 +507,27 @@ func TestEtcdUpdateImageRepository(t *testing.T) {
 	}
 }
 
+func TestEtcdUpdateImageRepositoryConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+
+	resp, _ := fakeClient.Set("/imageRepositories/foo", runtime.EncodeOrDie(api.ImageRepository{JSONBase: kubeapi.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcd(fakeClient)
+	stale := &api.ImageRepository{
+		JSONBase: kubeapi.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+		Tags:     map[string]string{"v1": "image1"},
+	}
+	fakeClient.Set("/imageRepositories/foo", runtime.EncodeOrDie(api.ImageRepository{
+		JSONBase: kubeapi.JSONBase{ID: "foo"},
+		Tags:     map[string]string{"v2": "image2"},
+	}), 0)
+
+	err := registry.UpdateImageRepository(stale)
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+}
+
 func TestEtcdDeleteImageRepositoryNotFound(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.Err = tools.EtcdErrorNotFound
@@ -8,6 +8,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	buildutil "github.com/openshift/origin/pkg/build/util"
 	"github.com/openshift/origin/pkg/image/api"
 	"github.com/openshift/origin/pkg/image/api/validation"
 	"github.com/openshift/origin/pkg/image/registry/image"
@@ -68,18 +69,27 @@ func (s *REST) Create(obj interface{}) (<-chan interface{}, error) {
 
 	//TODO apply metadata overrides
 
-	if repo.Tags == nil {
-		repo.Tags = make(map[string]string)
-	}
-	repo.Tags[mapping.Tag] = image.ID
-
 	return apiserver.MakeAsync(func() (interface{}, error) {
 		err = s.imageRegistry.CreateImage(&image)
 		if err != nil && !errors.IsAlreadyExists(err) {
 			return nil, err
 		}
 
-		err = s.imageRepositoryRegistry.UpdateImageRepository(repo)
+		for retries := 0; ; retries++ {
+			if repo.Tags == nil {
+				repo.Tags = make(map[string]string)
+			}
+			repo.Tags[mapping.Tag] = image.ID
+
+			err = s.imageRepositoryRegistry.UpdateImageRepository(repo)
+			if err == nil || !errors.IsConflict(err) || retries >= buildutil.MaxConflictRetries {
+				break
+			}
+			// the repository was modified since it was read, tag its current version
+			if repo, err = s.imageRepositoryRegistry.GetImageRepository(repo.ID); err != nil {
+				return nil, err
+			}
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -228,3 +228,59 @@ func TestCreateImageRepositoryMapping(t *testing.T) {
 		t.Errorf("Expected %s, got %s", e, a)
 	}
 }
+
+// conflictingImageRepositoryRegistry simulates a concurrent update of the
+// image repository, which is tagged between the read and the first update.
+type conflictingImageRepositoryRegistry struct {
+	*test.ImageRepositoryRegistry
+	conflicts int
+}
+
+func (r *conflictingImageRepositoryRegistry) UpdateImageRepository(repo *api.ImageRepository) error {
+	if r.conflicts > 0 {
+		r.conflicts--
+		return errors.NewConflict("imageRepository", repo.ID, fmt.Errorf("modified"))
+	}
+	return r.ImageRepositoryRegistry.UpdateImageRepository(repo)
+}
+
+func TestCreateImageRepositoryMappingConflict(t *testing.T) {
+	imageRegistry := test.NewImageRegistry()
+	imageRepositoryRegistry := &conflictingImageRepositoryRegistry{test.NewImageRepositoryRegistry(), 1}
+	imageRepositoryRegistry.ImageRepositories = &api.ImageRepositoryList{
+		Items: []api.ImageRepository{
+			{
+				JSONBase:              kubeapi.JSONBase{ID: "repo1"},
+				DockerImageRepository: "localhost:5000/someproject/somerepo",
+			},
+		},
+	}
+	imageRepositoryRegistry.ImageRepository = &api.ImageRepository{
+		JSONBase:              kubeapi.JSONBase{ID: "repo1"},
+		DockerImageRepository: "localhost:5000/someproject/somerepo",
+		Tags:                  map[string]string{"stable": "imageID0"},
+	}
+	storage := &REST{imageRegistry, imageRepositoryRegistry}
+
+	mapping := api.ImageRepositoryMapping{
+		DockerImageRepository: "localhost:5000/someproject/somerepo",
+		Image: api.Image{
+			JSONBase:             kubeapi.JSONBase{ID: "imageID1"},
+			DockerImageReference: "localhost:5000/someproject/somerepo:imageID1",
+		},
+		Tag: "latest",
+	}
+	ch, err := storage.Create(&mapping)
+	if err != nil {
+		t.Fatalf("Unexpected error creating mapping: %#v", err)
+	}
+	if status, ok := (<-ch).(*kubeapi.Status); !ok || status.Status != kubeapi.StatusSuccess {
+		t.Fatalf("Unexpected result: %#v", status)
+	}
+
+	repo, _ := imageRepositoryRegistry.GetImageRepository("repo1")
+	expected := map[string]string{"stable": "imageID0", "latest": "imageID1"}
+	if !reflect.DeepEqual(expected, repo.Tags) {
+		t.Errorf("Expected the concurrent tag to be kept, got %v", repo.Tags)
+	}
+}
@@ -0,0 +1,3 @@
+// Package kubelet extends the API of the kubelets run by OpenShift nodes with
+// the operations OpenShift needs to manage build pods.
+package kubelet
@@ -0,0 +1,98 @@
+package kubelet
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet"
+	"github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+)
+
+// ContainerLogsPrefix is the path under which the container logs are served.
+const ContainerLogsPrefix = "/containerLogs/"
+
+// PodInfoGetter returns the Docker containers of a pod run by the kubelet.
+type PodInfoGetter interface {
+	GetPodInfo(podFullName string) (kubeapi.PodInfo, error)
+}
+
+// ContainerLogger streams the output of a Docker container.
+type ContainerLogger interface {
+	Logs(opts docker.LogsOptions) error
+}
+
+// ContainerLogsHandler serves the output of the containers of the pods run by
+// a kubelet at /containerLogs/{podID}/{containerName}. The follow=true
+// parameter streams the output until the container exits.
+type ContainerLogsHandler struct {
+	pods   PodInfoGetter
+	docker ContainerLogger
+}
+
+// NewContainerLogsHandler creates a new ContainerLogsHandler.
+func NewContainerLogsHandler(pods PodInfoGetter, docker ContainerLogger) *ContainerLogsHandler {
+	return &ContainerLogsHandler{pods, docker}
+}
+
+// ServeHTTP writes the output of the container identified by the request path.
+func (h *ContainerLogsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		http.Error(w, fmt.Sprintf("Unsupported HTTP method %s!", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, ContainerLogsPrefix), "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		http.Error(w, "Expected a path of the form /containerLogs/{podID}/{containerName}", http.StatusBadRequest)
+		return
+	}
+	podID, containerName := parts[0], parts[1]
+
+	// pods scheduled through the API are run in the etcd namespace, see the
+	// podInfo operation of the kubelet
+	info, err := h.pods.GetPodInfo(kubelet.GetPodFullName(&kubelet.Pod{Name: podID, Namespace: "etcd"}))
+	if err == kubelet.ErrNoContainersInPod {
+		http.Error(w, fmt.Sprintf("Pod %s does not exist", podID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	container, ok := info[containerName]
+	if !ok || len(container.ID) == 0 {
+		http.Error(w, fmt.Sprintf("Pod %s has no container %s", podID, containerName), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	out := &flushWriter{w}
+	err = h.docker.Logs(docker.LogsOptions{
+		Container:    container.ID,
+		OutputStream: out,
+		ErrorStream:  out,
+		Stdout:       true,
+		Stderr:       true,
+		Follow:       req.URL.Query().Get("follow") == "true",
+	})
+	if err != nil {
+		glog.Errorf("Error streaming the output of container %s of pod %s: %v", containerName, podID, err)
+	}
+}
+
+// flushWriter flushes every write so that followed logs reach the client
+// as soon as they are produced.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if flusher, ok := fw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,79 @@
+package kubelet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kubeapi "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet"
+	"github.com/fsouza/go-dockerclient"
+)
+
+type fakePodInfoGetter struct {
+	podFullName string
+	info        kubeapi.PodInfo
+}
+
+func (g *fakePodInfoGetter) GetPodInfo(podFullName string) (kubeapi.PodInfo, error) {
+	g.podFullName = podFullName
+	if g.info == nil {
+		return nil, kubelet.ErrNoContainersInPod
+	}
+	return g.info, nil
+}
+
+type fakeContainerLogger struct {
+	opts docker.LogsOptions
+}
+
+func (l *fakeContainerLogger) Logs(opts docker.LogsOptions) error {
+	l.opts = opts
+	fmt.Fprint(opts.OutputStream, "Step 1 : FROM centos\n")
+	return nil
+}
+
+func getContainerLogs(handler http.Handler, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestContainerLogs(t *testing.T) {
+	pods := &fakePodInfoGetter{info: kubeapi.PodInfo{"docker-build": docker.Container{ID: "abc123"}}}
+	logger := &fakeContainerLogger{}
+	handler := NewContainerLogsHandler(pods, logger)
+
+	w := getContainerLogs(handler, "/containerLogs/build-docker-foo/docker-build?follow=true")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if e, a := "Step 1 : FROM centos\n", w.Body.String(); e != a {
+		t.Errorf("Expected %q, got %q", e, a)
+	}
+	if e, a := "build-docker-foo.etcd", pods.podFullName; e != a {
+		t.Errorf("Expected pod %s to be looked up, got %s", e, a)
+	}
+	if logger.opts.Container != "abc123" || !logger.opts.Follow || !logger.opts.Stdout || !logger.opts.Stderr {
+		t.Errorf("Unexpected log options %#v", logger.opts)
+	}
+}
+
+func TestContainerLogsNotFound(t *testing.T) {
+	pods := &fakePodInfoGetter{info: kubeapi.PodInfo{"docker-build": docker.Container{ID: "abc123"}}}
+	handler := NewContainerLogsHandler(pods, &fakeContainerLogger{})
+
+	if w := getContainerLogs(handler, "/containerLogs/build-docker-foo/sti-build"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing container, got %d", http.StatusNotFound, w.Code)
+	}
+	pods.info = nil
+	if w := getContainerLogs(handler, "/containerLogs/build-docker-foo/docker-build"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing pod, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := getContainerLogs(handler, "/containerLogs/build-docker-foo"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid path, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,4 @@
+// Package metrics contains counters and histograms which are exposed over HTTP
+// in the Prometheus text format, so that the build system can be monitored and
+// alerted on.
+package metrics
@@ -0,0 +1,273 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric is a named set of values, partitioned by label values, which writes
+// itself in the Prometheus text format.
+type Metric interface {
+	// Name returns the name the metric is exposed under
+	Name() string
+	// Write writes the help, type and values of the metric to w
+	Write(w io.Writer) error
+}
+
+// labelSet describes the labels of a metric and identifies its values
+type labelSet struct {
+	names []string
+}
+
+// key returns the key identifying the values of a metric for labelValues, it
+// panics if the number of values does not match the labels of the metric
+// since this is a programming error.
+func (l labelSet) key(labelValues []string) string {
+	if len(labelValues) != len(l.names) {
+		panic(fmt.Sprintf("expected %d label values, got %d", len(l.names), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// format returns the label pairs of labelValues in the Prometheus text format,
+// followed by extra pairs which are already formatted
+func (l labelSet) format(labelValues []string, extra ...string) string {
+	pairs := []string{}
+	for i, name := range l.names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(labelValues[i])))
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a value which only increases, such as the number of created builds
+type Counter struct {
+	name   string
+	help   string
+	labels labelSet
+
+	lock   sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter creates a counter whose values are partitioned by the given labels
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{
+		name:   name,
+		help:   help,
+		labels: labelSet{labels},
+		values: map[string]*counterValue{},
+	}
+}
+
+// Name returns the name of the counter
+func (c *Counter) Name() string {
+	return c.name
+}
+
+// Inc increments the counter for the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter for the given label values by delta, which must not
+// be negative
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	key := c.labels.key(labelValues)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: labelValues}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+// Value returns the current value of the counter for the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.labels.key(labelValues)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if value, ok := c.values[key]; ok {
+		return value.value
+	}
+	return 0
+}
+
+// Write writes the counter in the Prometheus text format
+func (c *Counter) Write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, escapeHelp(c.help), c.name); err != nil {
+		return err
+	}
+	for _, value := range c.snapshot() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labels.format(value.labelValues), formatFloat(value.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot copies the values of the counter sorted by label values, so that
+// they are written without holding the lock
+func (c *Counter) snapshot() []counterValue {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := []string{}
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]counterValue, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, *c.values[key])
+	}
+	return values
+}
+
+// Histogram counts observations, such as build durations, in buckets of
+// increasing upper bounds
+type Histogram struct {
+	name    string
+	help    string
+	labels  labelSet
+	buckets []float64
+
+	lock   sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, in
+// increasing order, whose values are partitioned by the given labels
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labelSet{labels},
+		buckets: buckets,
+		values:  map[string]*histogramValue{},
+	}
+}
+
+// ExponentialBuckets returns count bucket upper bounds, the first is start and
+// every following one is factor times the previous one
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// Name returns the name of the histogram
+func (h *Histogram) Name() string {
+	return h.name
+}
+
+// Observe adds value to the histogram for the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.labels.key(labelValues)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+// Count returns the number of observations for the given label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.labels.key(labelValues)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if v, ok := h.values[key]; ok {
+		return v.count
+	}
+	return 0
+}
+
+// Write writes the histogram in the Prometheus text format
+func (h *Histogram) Write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, escapeHelp(h.help), h.name); err != nil {
+		return err
+	}
+	for _, v := range h.snapshot() {
+		for i, bound := range h.buckets {
+			le := fmt.Sprintf("le=%q", formatFloat(bound))
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels.format(v.labelValues, le), v.counts[i]); err != nil {
+				return err
+			}
+		}
+		labels := h.labels.format(v.labelValues)
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labels.format(v.labelValues, `le="+Inf"`), v.count,
+			h.name, labels, formatFloat(v.sum),
+			h.name, labels, v.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot copies the values of the histogram sorted by label values, so that
+// they are written without holding the lock
+func (h *Histogram) snapshot() []histogramValue {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	keys := []string{}
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]histogramValue, 0, len(keys))
+	for _, key := range keys {
+		v := *h.values[key]
+		v.counts = append([]uint64(nil), v.counts...)
+		values = append(values, v)
+	}
+	return values
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
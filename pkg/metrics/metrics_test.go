@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	counter := NewCounter("builds_total", "Number of builds.", "status")
+	counter.Inc("complete")
+	counter.Inc("complete")
+	counter.Add(3, "failed")
+
+	if value := counter.Value("complete"); value != 2 {
+		t.Errorf("Expected 2 complete builds, got %v", value)
+	}
+	if value := counter.Value("error"); value != 0 {
+		t.Errorf("Expected no errored builds, got %v", value)
+	}
+
+	out := &bytes.Buffer{}
+	if err := counter.Write(out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `# HELP builds_total Number of builds.
+# TYPE builds_total counter
+builds_total{status="complete"} 2
+builds_total{status="failed"} 3
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+// incWriter increments a counter on every write, which deadlocks if the
+// counter is written while holding its lock
+type incWriter struct {
+	bytes.Buffer
+	counter *Counter
+}
+
+func (w *incWriter) Write(p []byte) (int, error) {
+	w.counter.Inc("complete")
+	return w.Buffer.Write(p)
+}
+
+func TestCounterWriteDoesNotHoldLock(t *testing.T) {
+	counter := NewCounter("builds_total", "Number of builds.", "status")
+	counter.Inc("complete")
+
+	out := &incWriter{counter: counter}
+	if err := counter.Write(out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`builds_total{status="complete"}`)) {
+		t.Errorf("Expected the counter to be written, got\n%s", out.String())
+	}
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	counter := NewCounter("created_total", "Number of created builds.")
+	counter.Inc()
+
+	out := &bytes.Buffer{}
+	counter.Write(out)
+	expected := `# HELP created_total Number of created builds.
+# TYPE created_total counter
+created_total 1
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestCounterEscapesLabelValues(t *testing.T) {
+	counter := NewCounter("errors_total", "Errors.", "message")
+	counter.Inc("a \"quoted\"\nvalue")
+
+	out := &bytes.Buffer{}
+	counter.Write(out)
+	expected := `# HELP errors_total Errors.
+# TYPE errors_total counter
+errors_total{message="a \"quoted\"\nvalue"} 1
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestCounterWrongLabelValues(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for missing label values")
+		}
+	}()
+	NewCounter("builds_total", "Number of builds.", "status").Inc()
+}
+
+func TestHistogram(t *testing.T) {
+	histogram := NewHistogram("build_duration_seconds", "Build duration.", []float64{1, 10}, "status")
+	histogram.Observe(0.5, "complete")
+	histogram.Observe(5, "complete")
+	histogram.Observe(20, "complete")
+
+	if count := histogram.Count("complete"); count != 3 {
+		t.Errorf("Expected 3 observations, got %d", count)
+	}
+
+	out := &bytes.Buffer{}
+	if err := histogram.Write(out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `# HELP build_duration_seconds Build duration.
+# TYPE build_duration_seconds histogram
+build_duration_seconds_bucket{status="complete",le="1"} 1
+build_duration_seconds_bucket{status="complete",le="10"} 2
+build_duration_seconds_bucket{status="complete",le="+Inf"} 3
+build_duration_seconds_sum{status="complete"} 25.5
+build_duration_seconds_count{status="complete"} 3
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	buckets := ExponentialBuckets(1, 2, 4)
+	expected := []float64{1, 2, 4, 8}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, buckets)
+	}
+	for i := range expected {
+		if buckets[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, buckets)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// Registry holds the metrics exposed by a process
+type Registry struct {
+	lock    sync.Mutex
+	metrics map[string]Metric
+}
+
+// DefaultRegistry is the registry the metrics of OpenShift components are
+// registered in and which is served by Handler
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]Metric{}}
+}
+
+// Register adds metrics to the registry. It panics if a metric with the same
+// name is already registered, which is a programming error.
+func (r *Registry) Register(metrics ...Metric) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, metric := range metrics {
+		if _, exists := r.metrics[metric.Name()]; exists {
+			panic(fmt.Sprintf("metric %s is already registered", metric.Name()))
+		}
+		r.metrics[metric.Name()] = metric
+	}
+}
+
+// Write writes all registered metrics, ordered by name, in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+	names := []string{}
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := []Metric{}
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.lock.Unlock()
+
+	for _, metric := range metrics {
+		if err := metric.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the registered metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := r.Write(w); err != nil {
+		glog.Errorf("Error writing metrics: %v", err)
+	}
+}
+
+// Handler returns the handler serving the metrics of DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	second := NewCounter("b_total", "Second.")
+	first := NewCounter("a_total", "First.")
+	registry.Register(second, first)
+	first.Inc()
+
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body := string(data)
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Expected a text content type, got %s", contentType)
+	}
+	if !strings.Contains(body, "a_total 1\n") {
+		t.Errorf("Expected the value of a_total, got %s", body)
+	}
+	if strings.Index(body, "a_total") > strings.Index(body, "b_total") {
+		t.Errorf("Expected metrics ordered by name, got %s", body)
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a duplicate metric")
+		}
+	}()
+	registry := NewRegistry()
+	registry.Register(NewCounter("a_total", "First."))
+	registry.Register(NewCounter("a_total", "Again."))
+}
@@ -70,7 +70,7 @@ func TestBuildConfigClient(t *testing.T) {
 		},
 		DesiredInput: api.BuildInput{
 			Type:         api.DockerBuildType,
-			SourceURI:    "http://my.docker/build",
+			Source:       api.BuildSource{Type: api.GitBuildSourceType, Git: &api.GitBuildSource{URI: "http://my.docker/build"}},
 			ImageTag:     "namespace/builtimage",
 			BuilderImage: "anImage",
 		},
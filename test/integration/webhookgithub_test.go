@@ -71,9 +71,9 @@ func TestWebhookGithubPush(t *testing.T) {
 			ID: "build100",
 		},
 		DesiredInput: buildapi.BuildInput{
-			Type:      buildapi.DockerBuildType,
-			SourceURI: "http://my.docker/build",
-			ImageTag:  "namespace/builtimage",
+			Type:     buildapi.DockerBuildType,
+			Source:   buildapi.BuildSource{Type: buildapi.GitBuildSourceType, Git: &buildapi.GitBuildSource{URI: "http://my.docker/build"}},
+			ImageTag: "namespace/builtimage",
 		},
 		Secret: "secret101",
 	}